@@ -0,0 +1,148 @@
+package optional
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const sourceDefaults = "defaults"
+
+// configDecoders decode config files by file extension.
+var configDecoders = map[string]func(r io.Reader, opts ...SourceOption) Value{
+	".json": DecodeJSONVal,
+	".xml":  DecodeXMLVal,
+}
+
+// Config loads a configuration from several layers, such as defaults, a
+// config file, the environment and command-line flags. The layers are deep
+// merged, later layers taking precedence over earlier ones, and the result
+// is run through the declared validation and processing steps before it is
+// aligned into a config struct.
+type Config struct {
+	layers []configLayer
+	files  []string
+	steps  []func(val Value) Value
+}
+
+type configLayer struct {
+	name string
+	load func() Value
+}
+
+// NewConfig returns a Config without any layers.
+func NewConfig() *Config {
+	return &Config{}
+}
+
+// Layer adds a layer whose keys are read with load. The name is reported by
+// Origin for the keys the layer supplies, unless the loaded value knows
+// better itself.
+func (c *Config) Layer(name string, load func() Value) *Config {
+	c.layers = append(c.layers, configLayer{name: name, load: load})
+	return c
+}
+
+// Defaults adds a layer of default values.
+func (c *Config) Defaults(val Value) *Config {
+	return c.Layer(sourceDefaults, func() Value {
+		return val
+	})
+}
+
+// File adds a layer read from a config file. The format is chosen by the
+// file extension, .json or .xml. The file must exist.
+func (c *Config) File(path string) *Config {
+	c.files = append(c.files, path)
+	return c.Layer("file "+path, func() Value {
+		return ConfigFileVal(path)
+	})
+}
+
+// Env adds a layer of environment variables, see EnvVal.
+func (c *Config) Env(prefix string, opts ...EnvOption) *Config {
+	return c.Layer(sourceEnv, func() Value {
+		return EnvVal(prefix, opts...)
+	})
+}
+
+// Flags adds a layer of the command-line flags the user set, see
+// FlagSetVal. Flags left at their default do not override earlier layers.
+func (c *Config) Flags(fs *flag.FlagSet) *Config {
+	return c.Layer(sourceFlag, func() Value {
+		return flagSetVal(fs, false)
+	})
+}
+
+// Validates adds a validation step run on the merged configuration.
+func (c *Config) Validates(validates ...validator) *Config {
+	c.steps = append(c.steps, func(val Value) Value {
+		return val.Validates(validates...).Value()
+	})
+	return c
+}
+
+// Processors adds a processing step run on the merged configuration.
+func (c *Config) Processors(ps ...processor) *Config {
+	c.steps = append(c.steps, func(val Value) Value {
+		return val.Processors(ps...).Value()
+	})
+	return c
+}
+
+// Files returns the paths of the config files added with File.
+func (c *Config) Files() []string {
+	return append([]string(nil), c.files...)
+}
+
+// Load reads and merges all layers, runs the validation and processing steps
+// in the order they were declared and aligns the result into dst, which must
+// be a pointer to a struct or nil. The returned value reports which layer
+// supplied each key through Origin and Origins.
+func (c *Config) Load(dst interface{}) (Value, error) {
+	vals := make([]Value, 0, len(c.layers))
+	for i := len(c.layers) - 1; i >= 0; i-- {
+		val := c.layers[i].load()
+		if err := val.GetError(); err != nil {
+			return val, fmt.Errorf("%s: %w", c.layers[i].name, err)
+		}
+		if val.src == "" {
+			val.src = c.layers[i].name
+		}
+		vals = append(vals, val)
+	}
+	merged := DeepMerge(vals...)
+	for i := range c.steps {
+		if err := merged.GetError(); err != nil {
+			return merged, err
+		}
+		merged = c.steps[i](merged)
+	}
+	if err := merged.GetError(); err != nil {
+		return merged, err
+	}
+	if dst != nil {
+		if err := merged.UnMarshal(dst); err != nil {
+			return merged, err
+		}
+	}
+	return merged, nil
+}
+
+// ConfigFileVal reads a config file and returns its content as a Value. The
+// format is chosen by the file extension, .json or .xml.
+func ConfigFileVal(path string) Value {
+	decode, ok := configDecoders[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return Value{err: fmt.Errorf("unsupported config file format %q", filepath.Ext(path))}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Value{err: err}
+	}
+	defer f.Close()
+	return decode(f)
+}
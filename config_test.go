@@ -0,0 +1,186 @@
+package optional_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorpher/optional/v2"
+)
+
+type testConfig struct {
+	Name string
+	DB   struct {
+		Host string
+		Port int
+		User string
+	}
+	Debug bool
+}
+
+func writeConfigFile(t *testing.T, dir, content string) string {
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "optional")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeConfigFile(t, dir, `{"name":"demo","db":{"host":"db.local","port":5432}}`)
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Bool("debug", false, "debug mode")
+	fs.String("name", "", "service name")
+	if err := fs.Parse([]string{"-debug"}); err != nil {
+		t.Fatal(err)
+	}
+	environ := func() []string {
+		return []string{"APP_DB__USER=admin", "APP_DB__HOST=db.internal"}
+	}
+
+	var cfg testConfig
+	value, err := optional.NewConfig().
+		Defaults(optional.ObjectVal(map[string]optional.Value{
+			"name": optional.StringVal("default"),
+			"db": optional.ObjectVal(map[string]optional.Value{
+				"port": optional.Int64Val(3306),
+				"user": optional.StringVal("root"),
+			}),
+		})).
+		File(path).
+		Env("APP_", optional.WithEnviron(environ)).
+		Flags(fs).
+		Validates(optional.Validate("name", optional.MustIsLetter())).
+		Processors(optional.Process("name", optional.ToUpper())).
+		Load(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "DEMO" || cfg.DB.Host != "db.internal" || cfg.DB.Port != 5432 || cfg.DB.User != "admin" || !cfg.Debug {
+		t.Errorf("wrong result\ngot:  %+v", cfg)
+	}
+	origins := map[string]string{
+		"name":    "file " + path,
+		"db.host": "environment variable",
+		"db.port": "file " + path,
+		"db.user": "environment variable",
+		"debug":   "flag",
+	}
+	for key, want := range origins {
+		if got := value.Origin(key); got != want {
+			t.Errorf("wrong result\nkey: %s\ngot:  %s\nwant: %s", key, got, want)
+		}
+	}
+
+	if _, err := optional.NewConfig().File(path).
+		Validates(optional.Validate("name", optional.MustIsDigit())).
+		Load(&cfg); err == nil {
+		t.Error("expected validation error")
+	}
+	if _, err := optional.NewConfig().File(filepath.Join(dir, "missing.json")).Load(&cfg); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "optional")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeConfigFile(t, dir, `{"name":"demo","db":{"port":5432}}`)
+
+	config := optional.NewConfig().
+		File(path).
+		Validates(optional.Validate("name", optional.MustIsLetter()))
+	r, err := optional.NewReloader(config, func() interface{} {
+		return &testConfig{}
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	r.OnChange(func(cfg interface{}) {
+		changes = append(changes, cfg.(*testConfig).Name)
+	})
+	var errs []error
+	r.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+	ch := r.Subscribe()
+
+	if ok, err := r.Check(); ok || err != nil {
+		t.Errorf("wrong result\ngot:  %v %v\nwant: false <nil>", ok, err)
+	}
+
+	writeConfigFile(t, dir, `{"name":"renamed","db":{"port":5433}}`)
+	if ok, err := r.Check(); !ok || err != nil {
+		t.Errorf("wrong result\ngot:  %v %v\nwant: true <nil>", ok, err)
+	}
+	if cfg := r.Current().(*testConfig); cfg.Name != "renamed" || cfg.DB.Port != 5433 {
+		t.Errorf("wrong result\ngot:  %+v", cfg)
+	}
+	if cfg := (<-ch).(*testConfig); cfg.Name != "renamed" {
+		t.Errorf("wrong result\ngot:  %+v", cfg)
+	}
+
+	// a rewrite of the same size within one modification time tick
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeConfigFile(t, dir, `{"name":"renamed","db":{"port":5434}}`)
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.Check(); !ok || err != nil {
+		t.Errorf("wrong result\ngot:  %v %v\nwant: true <nil>", ok, err)
+	}
+	if cfg := (<-ch).(*testConfig); cfg.DB.Port != 5434 {
+		t.Errorf("wrong result\ngot:  %+v", cfg)
+	}
+
+	// a config failing validation never replaces the current one
+	writeConfigFile(t, dir, `{"name":"bad name 1","db":{"port":1}}`)
+	if ok, err := r.Check(); ok || err == nil {
+		t.Errorf("wrong result\ngot:  %v %v\nwant: false error", ok, err)
+	}
+	if cfg := r.Current().(*testConfig); cfg.Name != "renamed" || cfg.DB.Port != 5434 {
+		t.Errorf("wrong result\ngot:  %+v", cfg)
+	}
+	if len(changes) != 2 || changes[1] != "renamed" || len(errs) != 1 {
+		t.Errorf("wrong result\nchanges: %v\nerrors:  %v", changes, errs)
+	}
+	select {
+	case cfg := <-ch:
+		t.Errorf("unexpected notification\ngot:  %+v", cfg)
+	default:
+	}
+
+	// the stamp of a file modified well before it was hashed is trusted,
+	// the file is not read again while its modification time and size stay
+	old := time.Now().Add(-time.Hour)
+	writeConfigFile(t, dir, `{"name":"stable","db":{"port":5435}}`)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.Check(); !ok || err != nil {
+		t.Errorf("wrong result\ngot:  %v %v\nwant: true <nil>", ok, err)
+	}
+	writeConfigFile(t, dir, `{"name":"stabla","db":{"port":5435}}`)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.Check(); ok || err != nil {
+		t.Errorf("wrong result\ngot:  %v %v\nwant: false <nil>", ok, err)
+	}
+}
@@ -1,9 +1,17 @@
 package optional
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"mime/multipart"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type converter struct {
@@ -26,6 +34,17 @@ type typeConverter interface {
 	Float32() (float32, error)
 	Float64() (float64, error)
 	Bool() (bool, error)
+	Time(layouts ...string) (time.Time, error)
+	Duration() (time.Duration, error)
+	Bytes() ([]byte, error)
+	Base64() (string, error)
+	Hex() (string, error)
+	BigFloat() (*big.Float, error)
+	BigInt() (*big.Int, error)
+	BigRat() (*big.Rat, error)
+	Decimal(scale int, mode big.RoundingMode) (Value, error)
+	List() ([]Value, error)
+	File() (*multipart.FileHeader, error)
 	Belief
 }
 
@@ -66,7 +85,11 @@ func (c converter) Int() (int, error) {
 	case String:
 		return strconv.Atoi(c.value.v.(string))
 	default:
-		return 0, ConvertError
+		i, err := c.BigInt()
+		if err != nil || !i.IsInt64() || int64(int(i.Int64())) != i.Int64() {
+			return 0, ConvertError
+		}
+		return int(i.Int64()), nil
 	}
 }
 func (c converter) Int8() (int8, error) {
@@ -127,6 +150,13 @@ func (c converter) Int64() (int64, error) {
 	if c.value.ty == String {
 		return strconv.ParseInt(c.value.v.(string), 10, 64)
 	}
+	if c.value.ty == Duration {
+		d, ok := c.value.v.(time.Duration)
+		if ok {
+			return int64(d), nil
+		}
+		return 0, ConvertError
+	}
 	i, err := c.Int()
 	return int64(i), err
 }
@@ -163,8 +193,15 @@ func (c converter) Uint() (uint, error) {
 			return uint(i), nil
 		}
 		return 0, ConvertError
+	case String:
+		i, err := strconv.ParseUint(c.value.v.(string), 10, 0)
+		return uint(i), err
 	default:
-		return 0, ConvertError
+		i, err := c.BigInt()
+		if err != nil || !i.IsUint64() || uint64(uint(i.Uint64())) != i.Uint64() {
+			return 0, ConvertError
+		}
+		return uint(i.Uint64()), nil
 	}
 }
 func (c converter) Uint8() (uint8, error) {
@@ -231,6 +268,13 @@ func (c converter) Float32() (float32, error) {
 			return 0, err
 		}
 		return float32(f64), err
+	default:
+		f, err := c.BigFloat()
+		if err != nil {
+			return 0, err
+		}
+		f32, _ := f.Float32()
+		return f32, nil
 	}
 	return 0, ConvertError
 }
@@ -245,9 +289,119 @@ func (c converter) Float64() (float64, error) {
 	if c.value.ty == String {
 		return strconv.ParseFloat(c.value.v.(string), 64)
 	}
-	i, err := c.Float32()
+	if c.value.ty == Float32 {
+		i, err := c.Float32()
+		return float64(i), err
+	}
+	f, err := c.BigFloat()
+	if err != nil {
+		return 0, err
+	}
+	f64, _ := f.Float64()
+	return f64, nil
+}
 
-	return float64(i), err
+// BigFloat returns a number, or a string holding one, as a big float. The
+// precision is at least 512 bits, so the result is exact for all numbers
+// but decimals with a fraction that is recurring in binary.
+func (c converter) BigFloat() (*big.Float, error) {
+	if f, ok := c.value.v.(*big.Float); ok && c.value.ty == BigNumber {
+		return new(big.Float).Copy(f), nil
+	}
+	if c.value.ty == String {
+		v, err := ParseBigNumberVal(c.value.v.(string))
+		if err != nil {
+			return nil, err
+		}
+		return v.v.(*big.Float), nil
+	}
+	r, err := c.BigRat()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetPrec(bigNumberPrec).SetRat(r), nil
+}
+
+// BigInt returns a number, or a string holding one, as a big integer. It
+// fails for a number with a fraction, which is never rounded.
+func (c converter) BigInt() (*big.Int, error) {
+	r, err := c.BigRat()
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() {
+		return nil, ConvertError
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// BigRat returns the exact value of a number, or of a string holding one in
+// decimal or fraction notation, such as "12.5" or "1/3", as a big rational.
+// Infinities and NaN can not be converted.
+func (c converter) BigRat() (*big.Rat, error) {
+	switch v := c.value.v.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case int8:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case int16:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case int32:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case int64:
+		return new(big.Rat).SetInt64(v), nil
+	case uint:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(uint64(v))), nil
+	case uint8:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case uint16:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case uint32:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(v)), nil
+	case float32:
+		return ratFloat64(float64(v))
+	case float64:
+		return ratFloat64(v)
+	case *big.Float:
+		if v.IsInf() {
+			return nil, ConvertError
+		}
+		r, _ := v.Rat(nil)
+		return r, nil
+	case *big.Int:
+		if c.value.ty.IsDecimalType() {
+			return decimalRat(v, c.value.ty.DecimalScale()), nil
+		}
+	case string:
+		if c.value.ty == String {
+			r, ok := new(big.Rat).SetString(strings.TrimSpace(v))
+			if !ok {
+				return nil, fmt.Errorf("a number is required")
+			}
+			return r, nil
+		}
+	}
+	return nil, ConvertError
+}
+
+func ratFloat64(f float64) (*big.Rat, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, ConvertError
+	}
+	return new(big.Rat).SetFloat64(f), nil
+}
+
+// Decimal returns a number, or a string holding one, as a value of
+// DecimalType(scale), rounded with the given mode, see DecimalVal.
+func (c converter) Decimal(scale int, mode big.RoundingMode) (Value, error) {
+	r, err := c.BigRat()
+	if err != nil {
+		return NilVal, err
+	}
+	val := DecimalVal(r, scale, mode)
+	return val, val.GetError()
 }
 
 func (c converter) isNumber() bool {
@@ -268,6 +422,21 @@ func (c converter) String() (string, error) {
 		if c.value.ty == Bool {
 			return strconv.FormatBool(c.value.v.(bool)), nil
 		}
+		if c.value.ty == Time {
+			return c.value.v.(time.Time).Format(time.RFC3339Nano), nil
+		}
+		if c.value.ty == Duration {
+			return c.value.v.(time.Duration).String(), nil
+		}
+		if c.value.ty == Bytes {
+			return string(c.value.v.([]byte)), nil
+		}
+		if c.value.ty == BigNumber {
+			return c.value.v.(*big.Float).Text('g', -1), nil
+		}
+		if c.value.ty.IsDecimalType() {
+			return decimalString(c.value.v.(*big.Int), c.value.ty.DecimalScale()), nil
+		}
 		if c.isNumber() {
 			if c.value.ty == Float32 {
 				f64, err := c.Float64()
@@ -291,9 +460,9 @@ func (c converter) String() (string, error) {
 			return strconv.FormatInt(i64, 10), nil
 		}
 	}
-	if c.value.ty.IsMapType() {
+	if c.value.ty.IsMapType() || c.value.ty.IsObjectType() || c.value.ty.IsListType() {
 		//todo
-		v, err := json.Marshal(c.value.v)
+		v, err := json.Marshal(c.value.goValue())
 		return string(v), err
 	}
 	return "", ConvertError
@@ -313,9 +482,113 @@ func (c converter) Bool() (bool, error) {
 	}
 }
 
-func (c converter) JSON() ([]byte, error) {
-	if c.value.ty.IsMapType() {
-		return json.Marshal(c.value.v)
+// Time returns the value as a point in time. Strings are parsed with the
+// first of the given layouts that accepts them, or of DefaultTimeLayouts if
+// no layouts are given. Integers are read with the first of the pseudo
+// layouts TimeLayoutUnix and TimeLayoutUnixMilli among the layouts.
+func (c converter) Time(layouts ...string) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	switch c.value.ty {
+	case Time:
+		t, ok := c.value.v.(time.Time)
+		if ok {
+			return t, nil
+		}
+	case String:
+		return parseTime(c.value.v.(string), layouts)
+	case Float32, Float64:
+	default:
+		if !c.isNumber() {
+			break
+		}
+		layout, ok := unixLayout(layouts)
+		if !ok {
+			break
+		}
+		n, err := c.Int64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return unixTime(n, layout), nil
+	}
+	return time.Time{}, ConvertError
+}
+
+// Duration returns the value as a time span. Strings are parsed with
+// time.ParseDuration, such as "1h30m", integers are taken as nanoseconds.
+func (c converter) Duration() (time.Duration, error) {
+	switch c.value.ty {
+	case Duration:
+		d, ok := c.value.v.(time.Duration)
+		if ok {
+			return d, nil
+		}
+	case String:
+		return time.ParseDuration(c.value.v.(string))
+	case Float32, Float64:
+	default:
+		if c.isNumber() {
+			n, err := c.Int64()
+			return time.Duration(n), err
+		}
+	}
+	return 0, ConvertError
+}
+
+// Bytes returns the value as binary data. A string is returned as its raw
+// bytes, use ParseBase64Val or ParseHexVal for encoded strings.
+func (c converter) Bytes() ([]byte, error) {
+	switch c.value.ty {
+	case Bytes:
+		b, ok := c.value.v.([]byte)
+		if ok {
+			return b, nil
+		}
+	case String:
+		return []byte(c.value.v.(string)), nil
+	}
+	return nil, ConvertError
+}
+
+// Base64 returns the bytes of a Bytes or String value as a standard base64
+// encoded string.
+func (c converter) Base64() (string, error) {
+	b, err := c.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Hex returns the bytes of a Bytes or String value as a hex encoded string.
+func (c converter) Hex() (string, error) {
+	b, err := c.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// List returns the elements of a list value.
+func (c converter) List() ([]Value, error) {
+	if c.value.ty.IsListType() {
+		elems, ok := c.value.v.([]Value)
+		if ok {
+			return elems, nil
+		}
+	}
+	return nil, ConvertError
+}
+
+// File returns the uploaded file of a File value.
+func (c converter) File() (*multipart.FileHeader, error) {
+	if c.value.ty.IsFileType() {
+		fh, ok := c.value.v.(*multipart.FileHeader)
+		if ok {
+			return fh, nil
+		}
 	}
 	return nil, ConvertError
 }
@@ -0,0 +1,241 @@
+package optional
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const sourceCSV = "csv column"
+
+// CSVCellError reports a cell of a CSV record that failed validation or
+// processing. Row counts the records of the file from 1, the header row
+// included. A record that could not be parsed at all is reported with an
+// empty Column.
+type CSVCellError struct {
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e CSVCellError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d column %s: %v", e.Row, e.Column, e.Err)
+}
+
+func (e CSVCellError) Unwrap() error {
+	return e.Err
+}
+
+// CSVReport sums up the records read by a CSVReader.
+type CSVReport struct {
+	Rows    int            // records read, the header row excluded
+	BadRows int            // records with at least one error
+	Errors  []CSVCellError // every bad cell, in the order read
+}
+
+// CSVOption configures a CSVReader.
+type CSVOption func(*csv.Reader)
+
+// WithCSVComma sets the field delimiter, a comma by default.
+func WithCSVComma(comma rune) CSVOption {
+	return func(r *csv.Reader) {
+		r.Comma = comma
+	}
+}
+
+// WithCSVComment skips lines starting with the comment character.
+func WithCSVComment(comment rune) CSVOption {
+	return func(r *csv.Reader) {
+		r.Comment = comment
+	}
+}
+
+// CSVReader reads the records of a CSV file one at a time. The first record
+// is the header row, it names the keys of the map Value each following
+// record becomes. Every record is run through the validation and processing
+// steps declared with Validates and Processors, all of them, so that the
+// report holds every bad cell rather than only the first one of a record.
+type CSVReader struct {
+	r       *csv.Reader
+	header  []string
+	steps   []csvStep
+	row     Value
+	line    int
+	report  CSVReport
+	err     error
+	started bool
+}
+
+type csvStep struct {
+	column string
+	run    func(row Value) Value
+}
+
+// NewCSVReader returns a CSVReader reading from r.
+func NewCSVReader(r io.Reader, opts ...CSVOption) *CSVReader {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	for _, opt := range opts {
+		opt(cr)
+	}
+	return &CSVReader{r: cr}
+}
+
+// Validates adds validation steps run on every record. Each validator is
+// run on its own, a failing one does not stop the others.
+func (c *CSVReader) Validates(validates ...validator) *CSVReader {
+	for i := range validates {
+		v := validates[i]
+		c.steps = append(c.steps, csvStep{column: v.name, run: func(row Value) Value {
+			if err := row.Validates(v).GetError(); err != nil {
+				row.err = err
+			}
+			return row
+		}})
+	}
+	return c
+}
+
+// Processors adds processing steps run on every record, in order. A failing
+// processor leaves its cell as it was.
+func (c *CSVReader) Processors(ps ...processor) *CSVReader {
+	for i := range ps {
+		p := ps[i]
+		c.steps = append(c.steps, csvStep{column: p.name, run: func(row Value) Value {
+			return row.Processors(p).Value()
+		}})
+	}
+	return c
+}
+
+// Next reads the next record, it returns false at the end of the input or
+// when reading fails, see Err. A record with bad cells is still returned,
+// Row then carries the error of its first bad cell.
+func (c *CSVReader) Next() bool {
+	if c.err != nil {
+		return false
+	}
+	if !c.started {
+		c.started = true
+		if !c.readHeader() {
+			return false
+		}
+	}
+	for {
+		record, err := c.r.Read()
+		if err == io.EOF {
+			return false
+		}
+		c.line++
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			// a malformed record does not stop the import
+			c.report.Rows++
+			c.report.BadRows++
+			c.report.Errors = append(c.report.Errors, CSVCellError{Row: c.line, Err: perr.Err})
+			continue
+		}
+		if err != nil {
+			c.err = err
+			return false
+		}
+		c.report.Rows++
+		c.row = c.rowVal(record)
+		return true
+	}
+}
+
+// Row returns the record read by the last call to Next.
+func (c *CSVReader) Row() Value {
+	return c.row
+}
+
+// Line returns the row number of the record returned by Row, the header row
+// being row 1.
+func (c *CSVReader) Line() int {
+	return c.line
+}
+
+// Err returns the error that stopped Next, if any. Bad cells are not
+// reported here but in Report.
+func (c *CSVReader) Err() error {
+	return c.err
+}
+
+// Report returns the report of the records read so far.
+func (c *CSVReader) Report() CSVReport {
+	report := c.report
+	report.Errors = append([]CSVCellError(nil), c.report.Errors...)
+	return report
+}
+
+// ReadAll reads the remaining records and returns those without bad cells,
+// along with the report of all records.
+func (c *CSVReader) ReadAll() ([]Value, CSVReport, error) {
+	var rows []Value
+	for c.Next() {
+		if c.row.GetError() == nil {
+			rows = append(rows, c.row)
+		}
+	}
+	return rows, c.Report(), c.Err()
+}
+
+func (c *CSVReader) readHeader() bool {
+	header, err := c.r.Read()
+	if err == io.EOF {
+		c.err = errors.New("csv header row is missing")
+		return false
+	}
+	if err != nil {
+		c.err = err
+		return false
+	}
+	c.line++
+	seen := make(map[string]bool, len(header))
+	c.header = make([]string, len(header))
+	for i := range header {
+		name := strings.TrimSpace(header[i])
+		if i == 0 {
+			// spreadsheets like to start the file with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if name == "" || seen[name] {
+			c.err = fmt.Errorf("csv header column %d must have a unique name", i+1)
+			return false
+		}
+		seen[name] = true
+		c.header[i] = name
+	}
+	return true
+}
+
+func (c *CSVReader) rowVal(record []string) Value {
+	vals := make(map[string]Value, len(c.header))
+	for i := range c.header {
+		vals[c.header[i]] = StringVal(record[i])
+	}
+	row := MapStringVal(vals).withSource(sourceCSV)
+	var first error
+	for _, step := range c.steps {
+		res := step.run(row)
+		if err := res.GetError(); err != nil {
+			c.report.Errors = append(c.report.Errors, CSVCellError{Row: c.line, Column: step.column, Err: err})
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		row = res
+	}
+	if first != nil {
+		c.report.BadRows++
+		row.err = first
+	}
+	return row
+}
@@ -0,0 +1,77 @@
+package optional_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gorpher/optional/v2"
+)
+
+func TestCSVReader(t *testing.T) {
+	data := "\ufeffname,age,email\n" +
+		"gorpher,24,gorpher@gmail.com\n" +
+		"bob,x1,\n" +
+		"alice,30\n" +
+		"carol,31,carol@example.com\n"
+	r := optional.NewCSVReader(strings.NewReader(data)).
+		Validates(
+			optional.Validate("age", optional.MustIsDigit()),
+			optional.Validate("email", optional.MustNotNil()),
+		).
+		Processors(optional.Process("name", optional.ToUpper()))
+
+	type person struct {
+		Name  string
+		Age   int
+		Email string
+	}
+	var people []person
+	var lines []int
+	for r.Next() {
+		row := r.Row()
+		if row.GetError() != nil {
+			continue
+		}
+		var p person
+		if err := row.UnMarshal(&p); err != nil {
+			t.Fatal(err)
+		}
+		people = append(people, p)
+		lines = append(lines, r.Line())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 2 || people[0].Name != "GORPHER" || people[0].Age != 24 ||
+		people[1].Name != "CAROL" || lines[1] != 5 {
+		t.Errorf("wrong result\ngot:  %+v %v", people, lines)
+	}
+
+	report := r.Report()
+	if report.Rows != 4 || report.BadRows != 2 || len(report.Errors) != 3 {
+		t.Fatalf("wrong result\ngot:  %+v", report)
+	}
+	want := []struct {
+		row    int
+		column string
+		err    string
+	}{
+		{3, "age", "row 3 column age: csv column `age` filed value must is digit"},
+		{3, "email", "row 3 column email: csv column `email` filed value must is not nil"},
+		{4, "", "row 4: wrong number of fields"},
+	}
+	for i, w := range want {
+		got := report.Errors[i]
+		if got.Row != w.row || got.Column != w.column || got.Error() != w.err {
+			t.Errorf("wrong result\ngot:  %d %q %s\nwant: %d %q %s", got.Row, got.Column, got.Error(), w.row, w.column, w.err)
+		}
+	}
+
+	rows, report, err := optional.NewCSVReader(strings.NewReader("a;b\n1;2\n"), optional.WithCSVComma(';')).ReadAll()
+	if err != nil || len(rows) != 1 || rows[0].GetMapValue("b").String() != "2" || report.Rows != 1 {
+		t.Errorf("wrong result\ngot:  %v %+v %v", rows, report, err)
+	}
+	if _, _, err := optional.NewCSVReader(strings.NewReader("a,a\n1,2\n")).ReadAll(); err == nil {
+		t.Error("expected an error for a duplicate header")
+	}
+}
@@ -0,0 +1,81 @@
+package optional
+
+import (
+	"os"
+	"strings"
+)
+
+const sourceEnv = "environment variable"
+
+// EnvOption configures EnvVal.
+type EnvOption func(o *envOptions)
+
+type envOptions struct {
+	separator string
+	keyCase   func(s string) string
+	environ   func() []string
+}
+
+// WithEnvSeparator sets the separator between the levels of a nested key.
+// The default is "__", so APP_DB__HOST becomes db.host.
+func WithEnvSeparator(sep string) EnvOption {
+	return func(o *envOptions) {
+		o.separator = sep
+	}
+}
+
+// WithEnvKeyCase sets a conversion applied to every lower cased key, such as
+// ToCamelCase to turn max_conns into maxConns. By default keys are only
+// lower cased.
+func WithEnvKeyCase(fn func(s string) string) EnvOption {
+	return func(o *envOptions) {
+		o.keyCase = fn
+	}
+}
+
+// WithEnviron sets the function the environment is read from, in the
+// key=value form of os.Environ. It lets tests provide their own variables
+// instead of touching the process environment.
+func WithEnviron(fn func() []string) EnvOption {
+	return func(o *envOptions) {
+		o.environ = fn
+	}
+}
+
+// EnvVal returns the environment variables whose names start with prefix as
+// a map Value. The prefix is stripped and the rest of the name is split at
+// the separator into nested keys, so with prefix APP_ the variable
+// APP_DB__HOST becomes the key host of the object db. A key that is a number
+// addresses a list element, so APP_HOSTS__0 and APP_HOSTS__1 become a list.
+// All values are String values.
+func EnvVal(prefix string, opts ...EnvOption) Value {
+	o := envOptions{
+		separator: "__",
+		environ:   os.Environ,
+	}
+	for i := range opts {
+		opts[i](&o)
+	}
+	m := map[string][]Value{}
+	for _, kv := range o.environ() {
+		i := strings.IndexByte(kv, '=')
+		if i <= 0 || !strings.HasPrefix(kv[:i], prefix) || len(kv[:i]) == len(prefix) {
+			continue
+		}
+		m[kv[len(prefix):i]] = []Value{StringVal(kv[i+1:])}
+	}
+	if len(m) == 0 {
+		return MapStringValEmpty().withSource(sourceEnv)
+	}
+	split := func(key string) []string {
+		segs := strings.Split(key, o.separator)
+		for i := range segs {
+			segs[i] = strings.ToLower(segs[i])
+			if o.keyCase != nil {
+				segs[i] = o.keyCase(segs[i])
+			}
+		}
+		return segs
+	}
+	return newSourceOptions(nil).pathValuesVal(m, split).withSource(sourceEnv)
+}
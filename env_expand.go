@@ -0,0 +1,133 @@
+package optional
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvResolver looks up the value of a variable referenced with ${NAME}. The
+// boolean reports whether the variable is set.
+type EnvResolver func(name string) (string, bool)
+
+// expandEnv replaces the variable references in s:
+//
+//	${VAR}          the value of VAR, an error if VAR is not set
+//	${VAR:-default} the value of VAR, or default if VAR is not set or empty
+//	${VAR:?message} the value of VAR, or an error with message if VAR is not
+//	                set or empty
+//
+// A default is used as it is, references in it are not expanded. Text
+// without a "${" is left alone, so a lone "$" needs no escaping.
+func expandEnv(s string, resolve EnvResolver) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		s = s[i+2:]
+		j := strings.IndexByte(s, '}')
+		if j < 0 {
+			return "", fmt.Errorf("unterminated variable reference ${%s", s)
+		}
+		v, err := expandRef(s[:j], resolve)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+		s = s[j+1:]
+	}
+}
+
+func expandRef(ref string, resolve EnvResolver) (string, error) {
+	name, op, arg := ref, byte(0), ""
+	if i := strings.IndexByte(ref, ':'); i >= 0 {
+		name = ref[:i]
+		if i+1 == len(ref) || (ref[i+1] != '-' && ref[i+1] != '?') {
+			return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+		}
+		op, arg = ref[i+1], ref[i+2:]
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+	}
+	v, ok := resolve(name)
+	switch {
+	case op == '-' && v == "":
+		return arg, nil
+	case op == '?' && v == "":
+		if arg == "" {
+			return "", fmt.Errorf("environment variable %s is not set or empty", name)
+		}
+		return "", errors.New(name + ": " + arg)
+	case !ok:
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+// expandEnvVal expands the variable references in a string value, or in all
+// strings nested in a list, map or object value. Other values are returned
+// unchanged. Errors name the nested key they occurred in.
+func expandEnvVal(val Value, source, name string, resolve EnvResolver) (Value, error) {
+	if val.v == nil {
+		return val, nil
+	}
+	switch {
+	case val.isString():
+		s, err := expandEnv(val.v.(string), resolve)
+		if err != nil {
+			return val, fmt.Errorf("%s filed %v", fieldName(source, name), err)
+		}
+		val.v = s
+	case val.IsListValue():
+		elems, _ := val.v.([]Value)
+		res := make([]Value, len(elems))
+		for i := range elems {
+			elem, err := expandEnvVal(elems[i], source, fmt.Sprintf("%s[%d]", name, i), resolve)
+			if err != nil {
+				return val, err
+			}
+			res[i] = elem
+		}
+		val.v = res
+	case val.IsObjectValue():
+		attrs, _ := val.v.(map[string]Value)
+		res := make(map[string]Value, len(attrs))
+		for key := range attrs {
+			attr, err := expandEnvVal(attrs[key], source, name+"."+key, resolve)
+			if err != nil {
+				return val, err
+			}
+			res[key] = attr
+		}
+		val.v = res
+	case val.IsMapValue():
+		raw, _ := val.v.(map[string]interface{})
+		types := val.ty.typeImpl.(typeStringMap).AttrType
+		res := make(map[string]interface{}, len(raw))
+		for key := range raw {
+			attr, err := expandEnvVal(Value{ty: types[key], v: raw[key]}, source, name+"."+key, resolve)
+			if err != nil {
+				return val, err
+			}
+			res[key] = attr.v
+		}
+		val.v = res
+	}
+	return val, nil
+}
+
+func defaultEnvResolver(resolve EnvResolver) EnvResolver {
+	if resolve == nil {
+		return os.LookupEnv
+	}
+	return resolve
+}
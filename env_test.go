@@ -0,0 +1,136 @@
+package optional_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorpher/optional/v2"
+)
+
+func TestEnvVal(t *testing.T) {
+	environ := func() []string {
+		return []string{
+			"APP_NAME=demo",
+			"APP_DB__HOST=localhost",
+			"APP_DB__PORT=5432",
+			"APP_DB__MAX_CONNS=10",
+			"APP_HOSTS__0=a.example.com",
+			"APP_HOSTS__1=b.example.com",
+			"OTHER_NAME=ignored",
+			"APP_=ignored",
+		}
+	}
+	var cfg struct {
+		Name string
+		DB   struct {
+			Host     string
+			Port     int
+			MaxConns int `json:"maxConns"`
+		}
+		Hosts []string
+	}
+	value := optional.EnvVal("APP_", optional.WithEnviron(environ), optional.WithEnvKeyCase(optional.ToCamelCase))
+	if err := value.Validates(
+		optional.Validate("name", optional.MustIsLetter()),
+		optional.Validate("hosts", optional.MustEach(optional.MustHasSuffix(".example.com"))),
+	).Value().Aligns(
+		optional.Align("name", &cfg.Name),
+		optional.Align("db", &cfg.DB),
+		optional.Align("hosts", &cfg.Hosts),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "demo" || cfg.DB.Host != "localhost" || cfg.DB.Port != 5432 ||
+		cfg.DB.MaxConns != 10 || len(cfg.Hosts) != 2 {
+		t.Errorf("wrong result\ngot:  %+v", cfg)
+	}
+	if got := value.Origin("db"); got != "environment variable" {
+		t.Errorf("wrong result\ngot:  %s", got)
+	}
+
+	empty := optional.EnvVal("NONE_", optional.WithEnviron(environ))
+	if err := empty.GetError(); err != nil || empty.Type().Len() != 0 {
+		t.Errorf("wrong result\ngot:  %v\nerr: %v", empty, err)
+	}
+
+	dotted := optional.EnvVal("APP_", optional.WithEnviron(environ), optional.WithEnvSeparator("."))
+	if got := dotted.GetMapValue("db__host").String(); got != "localhost" {
+		t.Errorf("wrong result\ngot:  %s", got)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	vars := map[string]string{"DB_HOST": "db.local", "DB_PASS": "secret", "EMPTY": ""}
+	resolve := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+	tests := []struct {
+		in   string
+		want string
+		err  string
+	}{
+		{in: "plain $HOME text", want: "plain $HOME text"},
+		{in: "${DB_HOST}:5432", want: "db.local:5432"},
+		{in: "${DB_HOST}/${DB_PASS}", want: "db.local/secret"},
+		{in: "${PORT:-5432}", want: "5432"},
+		{in: "${EMPTY:-fallback}", want: "fallback"},
+		{in: "${DB_HOST:-fallback}", want: "db.local"},
+		{in: "${EMPTY}", want: ""},
+		{in: "${DB_PASS:?password required}", want: "secret"},
+		{in: "${MISSING}", err: "body field `dsn` filed environment variable MISSING is not set"},
+		{in: "${MISSING:?password required}", err: "body field `dsn` filed MISSING: password required"},
+		{in: "${EMPTY:?}", err: "body field `dsn` filed environment variable EMPTY is not set or empty"},
+		{in: "${DB_HOST", err: "body field `dsn` filed unterminated variable reference ${DB_HOST"},
+		{in: "${DB_HOST:+x}", err: "body field `dsn` filed invalid variable reference ${DB_HOST:+x}"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"dsn":`+strconv.Quote(test.in)+`}`))
+		val := optional.HttpRequestBodyVal(req).
+			Processors(optional.Process("dsn", optional.ExpandEnv(resolve))).Value()
+		if test.err != "" {
+			if err := val.GetError(); err == nil || err.Error() != test.err {
+				t.Errorf("wrong result\nin:   %s\ngot:  %v\nwant: %s", test.in, err, test.err)
+			}
+			continue
+		}
+		if got := val.GetMapValue("dsn").String(); got != test.want {
+			t.Errorf("wrong result\nin:   %s\ngot:  %s\nwant: %s", test.in, got, test.want)
+		}
+	}
+
+	config := optional.MapStringVal(map[string]optional.Value{
+		"db": optional.ObjectVal(map[string]optional.Value{
+			"hosts": optional.ListVal([]optional.Value{
+				optional.StringVal("${DB_HOST}"),
+				optional.StringVal("${REPLICA}"),
+			}),
+		}),
+	})
+	err := config.Processors(optional.Process("db", optional.ExpandEnv(resolve))).GetError()
+	if want := "db.hosts[1] filed environment variable REPLICA is not set"; err == nil || err.Error() != want {
+		t.Errorf("wrong result\ngot:  %v\nwant: %s", err, want)
+	}
+	vars["REPLICA"] = "replica.local"
+	expanded := config.Processors(optional.Process("db", optional.ExpandEnv(resolve))).Value()
+	if got, _ := expanded.GetMapValue("db").Converter().String(); got != `{"hosts":["db.local","replica.local"]}` {
+		t.Errorf("wrong result\ngot:  %s", got)
+	}
+
+	sp := optional.StringVal("${DB_HOST}:${PORT:-5432}").StringProcessor().ExpandEnv(resolve)
+	if got := sp.(interface{ Value() optional.Value }).Value().String(); got != "db.local:5432" {
+		t.Errorf("wrong result\ngot:  %s", got)
+	}
+
+	// a failed expansion keeps the string
+	failed := optional.StringVal("${MISSING:?required}").StringProcessor().ExpandEnv(resolve).(interface {
+		Value() optional.Value
+		GetError() error
+	})
+	if got := failed.Value().String(); failed.GetError() == nil || got != "${MISSING:?required}" {
+		t.Errorf("wrong result\ngot:  %s %v", got, failed.GetError())
+	}
+}
@@ -0,0 +1,92 @@
+package optional
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"time"
+)
+
+const (
+	sourceFlag        = "flag"
+	sourceFlagDefault = "flag default"
+)
+
+// FlagSetVal returns the flags of a parsed flag set as a map Value. Every
+// flag is present with a value of its own type: a flag the user set holds the
+// parsed value, any other flag holds its default and is marked as a default,
+// see IsDefault. Flag names in dot or bracket notation such as db.host
+// become nested values, as they do for HttpRequestFormVal.
+func FlagSetVal(fs *flag.FlagSet) Value {
+	return flagSetVal(fs, true)
+}
+
+func flagSetVal(fs *flag.FlagSet, defaults bool) Value {
+	if !fs.Parsed() {
+		return Value{err: errors.New("flag set must be parsed")}
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	m := map[string][]Value{}
+	origins := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if !defaults && !set[f.Name] {
+			return
+		}
+		m[f.Name] = []Value{flagVal(f)}
+		if !set[f.Name] {
+			origins[strings.Join(splitKey(f.Name), ".")] = sourceFlagDefault
+		}
+	})
+	val := newSourceOptions([]SourceOption{WithNestedKeys()}).multiValuesVal(m).withSource(sourceFlag)
+	if val.GetError() == nil {
+		val.origins = &originSet{m: origins}
+	}
+	return val
+}
+
+// FlagArgsVal parses args with the flags defined on fs and returns the
+// result as FlagSetVal does.
+func FlagArgsVal(fs *flag.FlagSet, args []string) Value {
+	if err := fs.Parse(args); err != nil {
+		return Value{err: err}
+	}
+	return FlagSetVal(fs)
+}
+
+// IsDefault returns true if the named key holds a default value rather than
+// a value given by the user, such as a flag that was not set. Nested keys are
+// named with dots, such as db.host.
+func (val Value) IsDefault(name string) bool {
+	return val.Origin(name) == sourceFlagDefault
+}
+
+// flagVal returns the current value of a flag with the type of the flag.
+func flagVal(f *flag.Flag) Value {
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return StringVal(f.Value.String())
+	}
+	switch v := getter.Get().(type) {
+	case bool:
+		return BoolVal(v)
+	case int:
+		return IntVal(v)
+	case int64:
+		return Int64Val(v)
+	case uint:
+		return UintVal(v)
+	case uint64:
+		return Uint64Val(v)
+	case float64:
+		return Float64Val(v)
+	case string:
+		return StringVal(v)
+	case time.Duration:
+		return DurationVal(v)
+	default:
+		return StringVal(f.Value.String())
+	}
+}
@@ -0,0 +1,70 @@
+package optional_test
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/gorpher/optional/v2"
+)
+
+func TestFlagSetVal(t *testing.T) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.String("addr", ":8080", "listen address")
+	fs.Int("workers", 4, "number of workers")
+	fs.Bool("debug", false, "debug mode")
+	fs.Duration("timeout", time.Second, "request timeout")
+	fs.String("db.host", "localhost", "database host")
+
+	value := optional.FlagArgsVal(fs, []string{"-workers", "8", "-db.host", "db.internal"})
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		Name    string
+		Type    optional.Type
+		Default bool
+	}{
+		{"addr", optional.String, true},
+		{"workers", optional.Int, false},
+		{"debug", optional.Bool, true},
+		{"timeout", optional.Duration, true},
+	}
+	for _, test := range tests {
+		if got := value.GetMapValue(test.Name).Type(); !got.Equals(test.Type) {
+			t.Errorf("wrong result\nflag: %s\ngot:  %#v\nwant: %#v", test.Name, got, test.Type)
+		}
+		if got := value.IsDefault(test.Name); got != test.Default {
+			t.Errorf("wrong result\nflag: %s\ngot:  %v\nwant: %v", test.Name, got, test.Default)
+		}
+	}
+	if value.IsDefault("db.host") {
+		t.Error("db.host was set and must not be a default")
+	}
+
+	var cfg struct {
+		Addr    string
+		Workers int
+		Timeout time.Duration
+		DB      struct {
+			Host string
+		}
+	}
+	if err := value.Validates(
+		optional.Validate("workers", optional.MustIsNumberValue()),
+	).Value().Aligns(
+		optional.Align("addr", &cfg.Addr),
+		optional.Align("workers", &cfg.Workers),
+		optional.Align("timeout", &cfg.Timeout),
+		optional.Align("db", &cfg.DB),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":8080" || cfg.Workers != 8 || cfg.Timeout != time.Second || cfg.DB.Host != "db.internal" {
+		t.Errorf("wrong result\ngot:  %+v", cfg)
+	}
+
+	if err := optional.FlagSetVal(flag.NewFlagSet("new", flag.ContinueOnError)).GetError(); err == nil {
+		t.Error("expected error for unparsed flag set")
+	}
+}
@@ -1,30 +1,50 @@
 package optional
 
 import (
-	"encoding/json"
 	"errors"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 )
 
-func HttpRequestQueryVal(req *http.Request) Value {
-	if err := req.ParseForm(); err != nil {
+// HttpRequestQueryVal returns the url query parameters of the request as a
+// map Value. Repeated keys are handled according to WithMultiValue, and keys
+// in bracket or dot notation such as user[name] become nested values if
+// WithNestedKeys is given.
+func HttpRequestQueryVal(req *http.Request, opts ...SourceOption) Value {
+	o := newSourceOptions(opts)
+	if o.maxBytes > 0 && int64(len(req.URL.RawQuery)) > o.maxBytes {
+		return Value{
+			err: &LimitError{Limit: LimitBytes, Max: o.maxBytes},
+		}
+	}
+	queries, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
 		return Value{
 			err: err,
 		}
 	}
-	m := map[string]Value{}
-	queries := req.URL.Query()
-	for k, v := range queries {
-		m[k] = StringVal(v[0])
+	if err := o.checkValues(queries); err != nil {
+		return Value{
+			err: err,
+		}
 	}
-	return Value{}
+	return o.limit(o.valuesVal(queries)).withSource(sourceQuery)
 }
 
-func HttpRequestFormVal(req *http.Request) Value {
+// HttpRequestFormVal returns the parsed form of the request, both the url
+// query and the urlencoded body, as a map Value. Keys are handled in the same
+// way as by HttpRequestQueryVal.
+func HttpRequestFormVal(req *http.Request, opts ...SourceOption) Value {
+	o := newSourceOptions(opts)
+	if o.maxBytes > 0 && int64(len(req.URL.RawQuery)) > o.maxBytes {
+		return Value{
+			err: &LimitError{Limit: LimitBytes, Max: o.maxBytes},
+		}
+	}
+	o.limitBody(req)
 	if err := req.ParseForm(); err != nil {
 		return Value{
-			err: err,
+			err: limitError(err),
 		}
 	}
 	// form中必须有参数和值
@@ -33,25 +53,72 @@ func HttpRequestFormVal(req *http.Request) Value {
 			err: errors.New("没有期望的请求参数和值"),
 		}
 	}
-	m := map[string]Value{}
-	// 只获取第一个参数的值
-	for k, v := range req.Form {
-		m[k] = StringVal(v[0])
+	if err := o.checkValues(req.Form); err != nil {
+		return Value{
+			err: err,
+		}
 	}
-	return MapStringVal(m)
+	return o.limit(o.valuesVal(req.Form)).withSource(sourceForm)
 }
 
-func HttpRequestBodyVal(req *http.Request) Value {
-	var val interface{}
-	json.NewDecoder(req.Body).Decode(&val)
-	body, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		return Value{err: err}
+// HttpRequestBodyVal decodes the JSON request body into a Value, see
+// DecodeJSONVal for how JSON values are mapped onto types.
+func HttpRequestBodyVal(req *http.Request, opts ...SourceOption) Value {
+	if req.Body == nil {
+		return Value{err: errors.New("没有期望的请求参数和值")}
 	}
-	err = json.Unmarshal(body, &val)
-	if err != nil {
-		return Value{err: err}
+	return DecodeJSONVal(req.Body, opts...).withSource(sourceBody)
+}
+
+// HttpRequestHeaderVal returns the request headers as a map Value. Keys are
+// canonical header names as returned by http.CanonicalHeaderKey, such as
+// Authorization or X-Request-Id, and are looked up case-insensitively, so
+// X-Request-ID finds X-Request-Id. Repeated headers are handled according to
+// WithMultiValue.
+func HttpRequestHeaderVal(req *http.Request, opts ...SourceOption) Value {
+	o := newSourceOptions(opts)
+	o.nestedKeys = false
+	m := make(map[string][]Value, len(req.Header))
+	for k, vs := range req.Header {
+		key := http.CanonicalHeaderKey(k)
+		m[key] = append(m[key], stringVals(vs)...)
+	}
+	return o.limit(o.multiValuesVal(m)).withSource(sourceHeader)
+}
+
+// HttpRequestCookieVal returns the request cookies as a map Value keyed by
+// cookie name. Cookies sent more than once are handled according to
+// WithMultiValue.
+func HttpRequestCookieVal(req *http.Request, opts ...SourceOption) Value {
+	o := newSourceOptions(opts)
+	o.nestedKeys = false
+	cookies := req.Cookies()
+	m := make(map[string][]Value, len(cookies))
+	for i := range cookies {
+		m[cookies[i].Name] = append(m[cookies[i].Name], StringVal(cookies[i].Value))
+	}
+	return o.limit(o.multiValuesVal(m)).withSource(sourceCookie)
+}
+
+// PathParams returns the path parameters a router matched for the request,
+// keyed by parameter name.
+type PathParams func(req *http.Request) map[string]string
+
+// HttpRequestPathVal returns the path parameters of the request as a map
+// Value. The parameters are read with params, so any router can be used by
+// passing a function that asks it for the parameters of the request.
+func HttpRequestPathVal(req *http.Request, params PathParams, opts ...SourceOption) Value {
+	if params == nil {
+		return Value{
+			err: errors.New("path params function must not be nil"),
+		}
+	}
+	o := newSourceOptions(opts)
+	o.nestedKeys = false
+	ps := params(req)
+	m := make(map[string][]Value, len(ps))
+	for k, v := range ps {
+		m[k] = []Value{StringVal(v)}
 	}
-	//todo 添加对象解析
-	return Value{v: val, err: errors.New("TODO")}
+	return o.limit(o.multiValuesVal(m)).withSource(sourcePath)
 }
@@ -0,0 +1,108 @@
+package optional
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Decoder builds a Value from a request whose body has a particular media
+// type.
+type Decoder func(req *http.Request, opts ...SourceOption) Value
+
+var decoders = struct {
+	sync.RWMutex
+	m map[string]Decoder
+}{
+	m: map[string]Decoder{
+		"application/x-www-form-urlencoded": HttpRequestFormVal,
+		"multipart/form-data":               HttpRequestMultipartVal,
+		"application/json":                  HttpRequestBodyVal,
+	},
+}
+
+// RegisterDecoder registers the decoder HttpRequestVal uses for request
+// bodies of the given media type, such as application/json. It replaces any
+// decoder already registered for that media type. The returned function
+// restores the decoder registered before, if any, such as at the end of a
+// test.
+func RegisterDecoder(mediaType string, dec Decoder) (restore func()) {
+	mediaType = strings.ToLower(mediaType)
+	decoders.Lock()
+	defer decoders.Unlock()
+	prev, ok := decoders.m[mediaType]
+	decoders.m[mediaType] = dec
+	return func() {
+		decoders.Lock()
+		defer decoders.Unlock()
+		if ok {
+			decoders.m[mediaType] = prev
+		} else {
+			delete(decoders.m, mediaType)
+		}
+	}
+}
+
+func lookupDecoder(mediaType string) (Decoder, bool) {
+	decoders.RLock()
+	defer decoders.RUnlock()
+	if dec, ok := decoders.m[mediaType]; ok {
+		return dec, true
+	}
+	// structured syntax suffixes, such as application/problem+json
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		dec, ok := decoders.m["application/"+mediaType[i+1:]]
+		return dec, ok
+	}
+	return nil, false
+}
+
+// UnsupportedMediaTypeError is returned by HttpRequestVal when no decoder is
+// registered for the Content-Type of the request.
+type UnsupportedMediaTypeError struct {
+	MediaType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	if e.MediaType == "" {
+		return "missing request content type"
+	}
+	return fmt.Sprintf("unsupported request content type %q", e.MediaType)
+}
+
+// StatusCode returns the http status code for the error, 415 Unsupported
+// Media Type.
+func (e *UnsupportedMediaTypeError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+// HttpRequestVal returns the input of the request as a Value, choosing the
+// source by the request method and Content-Type. Requests without a body,
+// such as GET requests, use the url query. Other requests are decoded by the
+// decoder registered for their media type, see RegisterDecoder. An
+// *UnsupportedMediaTypeError is returned if there is none.
+func HttpRequestVal(req *http.Request, opts ...SourceOption) Value {
+	contentType := req.Header.Get("Content-Type")
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return HttpRequestQueryVal(req, opts...)
+	}
+	if contentType == "" && (req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0) {
+		return HttpRequestQueryVal(req, opts...)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Value{
+			err: &UnsupportedMediaTypeError{MediaType: contentType},
+		}
+	}
+	dec, ok := lookupDecoder(mediaType)
+	if !ok {
+		return Value{
+			err: &UnsupportedMediaTypeError{MediaType: mediaType},
+		}
+	}
+	return dec(req, opts...)
+}
@@ -0,0 +1,203 @@
+package optional
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// splitKey splits a form key written in bracket or dot notation into its
+// path segments, so that user[tags][] becomes user, tags and an empty
+// segment meaning "append", and items[0].price becomes items, 0 and price.
+// A key with unbalanced brackets is returned as a single segment.
+func splitKey(key string) []string {
+	var segs []string
+	i := strings.IndexAny(key, "[.")
+	if i <= 0 {
+		return []string{key}
+	}
+	segs = append(segs, key[:i])
+	rest := key[i:]
+	for rest != "" {
+		switch rest[0] {
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return []string{key}
+			}
+			segs = append(segs, rest[1:end])
+			rest = rest[end+1:]
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, "[.")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return []string{key}
+			}
+			segs = append(segs, rest[:end])
+			rest = rest[end:]
+		default:
+			return []string{key}
+		}
+	}
+	return segs
+}
+
+type keyNodeKind int
+
+const (
+	keyNodeNone keyNodeKind = iota
+	keyNodeLeaf
+	keyNodeObject
+	keyNodeList
+)
+
+// keyNode is one level of the tree built from nested form keys.
+type keyNode struct {
+	kind     keyNodeKind
+	values   []Value
+	attrs    map[string]*keyNode
+	indexed  map[int]*keyNode
+	appended []*keyNode
+}
+
+func (n *keyNode) setKind(kind keyNodeKind, path string) error {
+	if n.kind == keyNodeNone {
+		n.kind = kind
+		return nil
+	}
+	if n.kind != kind {
+		return fmt.Errorf("%s filed is used both as a value and as a nested field", path)
+	}
+	return nil
+}
+
+func (n *keyNode) insert(segs []string, vs []Value, path string) error {
+	if len(segs) == 0 {
+		if err := n.setKind(keyNodeLeaf, path); err != nil {
+			return err
+		}
+		n.values = append(n.values, vs...)
+		return nil
+	}
+	seg, rest := segs[0], segs[1:]
+	if seg == "" {
+		if err := n.setKind(keyNodeList, path); err != nil {
+			return err
+		}
+		path += "[]"
+		if len(rest) == 0 {
+			for i := range vs {
+				n.appended = append(n.appended, &keyNode{kind: keyNodeLeaf, values: vs[i : i+1]})
+			}
+			return nil
+		}
+		child := &keyNode{}
+		n.appended = append(n.appended, child)
+		return child.insert(rest, vs, path)
+	}
+	if idx, err := strconv.Atoi(seg); err == nil && idx >= 0 && n.kind != keyNodeObject {
+		if err := n.setKind(keyNodeList, path); err != nil {
+			return err
+		}
+		if n.indexed == nil {
+			n.indexed = map[int]*keyNode{}
+		}
+		child, ok := n.indexed[idx]
+		if !ok {
+			child = &keyNode{}
+			n.indexed[idx] = child
+		}
+		return child.insert(rest, vs, path+"["+seg+"]")
+	}
+	if err := n.setKind(keyNodeObject, path); err != nil {
+		return err
+	}
+	if n.attrs == nil {
+		n.attrs = map[string]*keyNode{}
+	}
+	child, ok := n.attrs[seg]
+	if !ok {
+		child = &keyNode{}
+		n.attrs[seg] = child
+	}
+	return child.insert(rest, vs, path+"."+seg)
+}
+
+func (n *keyNode) value(o sourceOptions, path string) (Value, error) {
+	switch n.kind {
+	case keyNodeObject:
+		attrs := make(map[string]Value, len(n.attrs))
+		for k, child := range n.attrs {
+			val, err := child.value(o, path+"."+k)
+			if err != nil {
+				return NilVal, err
+			}
+			attrs[k] = val
+		}
+		return ObjectVal(attrs), nil
+	case keyNodeList:
+		indexes := make([]int, 0, len(n.indexed))
+		for idx := range n.indexed {
+			indexes = append(indexes, idx)
+		}
+		sort.Ints(indexes)
+		children := make([]*keyNode, 0, len(indexes)+len(n.appended))
+		for _, idx := range indexes {
+			children = append(children, n.indexed[idx])
+		}
+		children = append(children, n.appended...)
+		elems := make([]Value, len(children))
+		for i, child := range children {
+			val, err := child.value(o, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return NilVal, err
+			}
+			elems[i] = val
+		}
+		list := unifiedListVal(elems)
+		return list, list.GetError()
+	default:
+		return o.multiVal(path, n.values)
+	}
+}
+
+// nestedValuesVal converts values whose keys use bracket or dot notation into
+// a map Value holding nested object and list values.
+func (o sourceOptions) nestedValuesVal(values map[string][]Value) Value {
+	return o.pathValuesVal(values, splitKey)
+}
+
+// pathValuesVal converts values into a map Value holding nested object and
+// list values, using split to turn each key into its path segments.
+func (o sourceOptions) pathValuesVal(values map[string][]Value, split func(key string) []string) Value {
+	root := &keyNode{kind: keyNodeObject, attrs: map[string]*keyNode{}}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		segs := split(k)
+		child, ok := root.attrs[segs[0]]
+		if !ok {
+			child = &keyNode{}
+			root.attrs[segs[0]] = child
+		}
+		if err := child.insert(segs[1:], values[k], segs[0]); err != nil {
+			return Value{err: err}
+		}
+	}
+	m := make(map[string]Value, len(root.attrs))
+	for k, child := range root.attrs {
+		val, err := child.value(o, k)
+		if err != nil {
+			return Value{err: err}
+		}
+		m[k] = val
+	}
+	return MapStringVal(m)
+}
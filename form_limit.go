@@ -0,0 +1,235 @@
+package optional
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// LimitKind names the limit a LimitError is about.
+type LimitKind string
+
+const (
+	LimitBytes        LimitKind = "bytes"
+	LimitDepth        LimitKind = "depth"
+	LimitKeys         LimitKind = "keys"
+	LimitStringLength LimitKind = "string length"
+	LimitArrayLength  LimitKind = "array length"
+)
+
+// LimitError is returned by a source whose input exceeds one of the limits
+// set with WithMaxBytes, WithMaxDepth, WithMaxKeys, WithMaxStringLength or
+// WithMaxArrayLength.
+type LimitError struct {
+	Limit LimitKind
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("input exceeds the maximum %s of %d", e.Limit, e.Max)
+}
+
+// StatusCode returns the http status code for the error, 413 Request Entity
+// Too Large for the byte limit and 400 Bad Request for the others.
+func (e *LimitError) StatusCode() int {
+	if e.Limit == LimitBytes {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// WithMaxBytes limits the size of the input a source reads, such as the
+// request body or the raw url query.
+func WithMaxBytes(n int64) SourceOption {
+	return func(o *sourceOptions) {
+		o.maxBytes = n
+	}
+}
+
+// WithMaxDepth limits how deeply objects and lists may be nested. A flat map
+// has a depth of 1.
+func WithMaxDepth(n int) SourceOption {
+	return func(o *sourceOptions) {
+		o.maxDepth = n
+	}
+}
+
+// WithMaxKeys limits the total number of keys of all maps and objects of the
+// input.
+func WithMaxKeys(n int) SourceOption {
+	return func(o *sourceOptions) {
+		o.maxKeys = n
+	}
+}
+
+// WithMaxStringLength limits the length in bytes of every string of the
+// input.
+func WithMaxStringLength(n int) SourceOption {
+	return func(o *sourceOptions) {
+		o.maxStringLength = n
+	}
+}
+
+// WithMaxArrayLength limits the number of elements of every list of the
+// input.
+func WithMaxArrayLength(n int) SourceOption {
+	return func(o *sourceOptions) {
+		o.maxArrayLength = n
+	}
+}
+
+// limitReader returns r limited to the configured number of bytes. Reading
+// past the limit fails with a *LimitError.
+func (o sourceOptions) limitReader(r io.Reader) io.Reader {
+	if o.maxBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, n: o.maxBytes, max: o.maxBytes}
+}
+
+// limitBody limits the body of the request to the configured number of bytes.
+func (o sourceOptions) limitBody(req *http.Request) {
+	if o.maxBytes <= 0 || req.Body == nil {
+		return
+	}
+	req.Body = &limitedBody{
+		Reader: o.limitReader(req.Body),
+		Closer: req.Body,
+	}
+}
+
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// only fail if there really is more input
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			return 0, &LimitError{Limit: LimitBytes, Max: l.max}
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// limitError returns the *LimitError wrapped in err, if any, so that errors
+// of the reading code do not hide it.
+func limitError(err error) error {
+	var le *LimitError
+	if errors.As(err, &le) {
+		return le
+	}
+	return err
+}
+
+// limit checks the built value against the configured limits. The JSON and
+// XML decoders and checkValues check them before the value is built already,
+// this check catches what they can not tell, such as the nesting of
+// repeated keys.
+func (o sourceOptions) limit(val Value) Value {
+	if val.GetError() != nil {
+		return val
+	}
+	keys := 0
+	if err := o.checkLimits(val, 1, &keys); err != nil {
+		return Value{err: err}
+	}
+	return val
+}
+
+func (o sourceOptions) checkLimits(val Value, depth int, keys *int) error {
+	switch {
+	case val.IsListValue():
+		if o.maxDepth > 0 && depth > o.maxDepth {
+			return &LimitError{Limit: LimitDepth, Max: int64(o.maxDepth)}
+		}
+		elems, _ := val.Converter().List()
+		if o.maxArrayLength > 0 && len(elems) > o.maxArrayLength {
+			return &LimitError{Limit: LimitArrayLength, Max: int64(o.maxArrayLength)}
+		}
+		for i := range elems {
+			if err := o.checkLimits(elems[i], depth+1, keys); err != nil {
+				return err
+			}
+		}
+	case val.IsMapValue() || val.IsObjectValue():
+		if o.maxDepth > 0 && depth > o.maxDepth {
+			return &LimitError{Limit: LimitDepth, Max: int64(o.maxDepth)}
+		}
+		names := val.attrNames()
+		*keys += len(names)
+		if o.maxKeys > 0 && *keys > o.maxKeys {
+			return &LimitError{Limit: LimitKeys, Max: int64(o.maxKeys)}
+		}
+		for _, name := range names {
+			if err := o.checkLimits(val.GetMapValue(name), depth+1, keys); err != nil {
+				return err
+			}
+		}
+	case val.isString() && !val.IsNull():
+		if o.maxStringLength > 0 && len(val.v.(string)) > o.maxStringLength {
+			return &LimitError{Limit: LimitStringLength, Max: int64(o.maxStringLength)}
+		}
+	}
+	return nil
+}
+
+// checkValues checks the parsed pairs of a query or form against the
+// configured limits before the nested value is built from them. The keys of
+// the nested value are counted from the path of each key, such as
+// user[name], an appended or indexed list element is not a key.
+func (o sourceOptions) checkValues(values url.Values) error {
+	paths := map[string]struct{}{}
+	for key, vs := range values {
+		if o.maxArrayLength > 0 && len(vs) > o.maxArrayLength &&
+			(o.multiValue == MultiValueAuto || o.multiValue == MultiValueAll) {
+			return &LimitError{Limit: LimitArrayLength, Max: int64(o.maxArrayLength)}
+		}
+		if o.maxStringLength > 0 {
+			for i := range vs {
+				if len(vs[i]) > o.maxStringLength {
+					return &LimitError{Limit: LimitStringLength, Max: int64(o.maxStringLength)}
+				}
+			}
+		}
+		segs := []string{key}
+		if o.nestedKeys {
+			segs = splitKey(key)
+		}
+		if o.maxDepth > 0 && len(segs) > o.maxDepth {
+			return &LimitError{Limit: LimitDepth, Max: int64(o.maxDepth)}
+		}
+		path := ""
+		for i, seg := range segs {
+			path += "[" + seg + "]"
+			if i > 0 {
+				if _, err := strconv.Atoi(seg); err == nil || seg == "" {
+					continue
+				}
+			}
+			paths[path] = struct{}{}
+		}
+		if o.maxKeys > 0 && len(paths) > o.maxKeys {
+			return &LimitError{Limit: LimitKeys, Max: int64(o.maxKeys)}
+		}
+	}
+	return nil
+}
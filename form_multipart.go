@@ -0,0 +1,36 @@
+package optional
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HttpRequestMultipartVal parses a multipart/form-data request body and
+// returns its text fields as String values and its uploads as File values in
+// one map Value. Keys are handled in the same way as by HttpRequestFormVal,
+// so a field holding several files becomes a list of File.
+func HttpRequestMultipartVal(req *http.Request, opts ...SourceOption) Value {
+	o := newSourceOptions(opts)
+	o.limitBody(req)
+	if err := req.ParseMultipartForm(o.maxMemory); err != nil {
+		return Value{
+			err: limitError(err),
+		}
+	}
+	form := req.MultipartForm
+	if len(form.Value) == 0 && len(form.File) == 0 {
+		return Value{
+			err: errors.New("没有期望的请求参数和值"),
+		}
+	}
+	m := make(map[string][]Value, len(form.Value)+len(form.File))
+	for k, vs := range form.Value {
+		m[k] = stringVals(vs)
+	}
+	for k, fhs := range form.File {
+		for i := range fhs {
+			m[k] = append(m[k], FileVal(fhs[i]))
+		}
+	}
+	return o.limit(o.multiValuesVal(m)).withSource(sourceForm)
+}
@@ -0,0 +1,139 @@
+package optional
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// MultiValue decides how a request source treats a key that is sent more
+// than once, such as ?id=1&id=2.
+type MultiValue int
+
+const (
+	// MultiValueAuto keeps a key sent once as a String and turns a repeated
+	// key into a list of String.
+	MultiValueAuto MultiValue = iota
+	// MultiValueFirst keeps only the first value of a repeated key.
+	MultiValueFirst
+	// MultiValueLast keeps only the last value of a repeated key.
+	MultiValueLast
+	// MultiValueAll always produces a list of String, even for a key sent once.
+	MultiValueAll
+	// MultiValueError fails the source when a key is repeated.
+	MultiValueError
+)
+
+const defaultMaxMemory = 32 << 20
+
+// SourceOption configures how a request source builds its Value.
+type SourceOption func(o *sourceOptions)
+
+type sourceOptions struct {
+	multiValue      MultiValue
+	nestedKeys      bool
+	maxMemory       int64
+	maxBytes        int64
+	maxDepth        int
+	maxKeys         int
+	maxStringLength int
+	maxArrayLength  int
+}
+
+// WithMultiValue sets the policy for keys that carry more than one value.
+// The default is MultiValueAuto.
+func WithMultiValue(policy MultiValue) SourceOption {
+	return func(o *sourceOptions) {
+		o.multiValue = policy
+	}
+}
+
+// WithNestedKeys parses the bracket and dot notation of keys such as
+// user[name], user[tags][] or items[0].price into nested object and list
+// values. Without it keys are kept as they are. A key used both as a value
+// and as a nested field, such as a=1&a[b]=2, fails the source.
+func WithNestedKeys() SourceOption {
+	return func(o *sourceOptions) {
+		o.nestedKeys = true
+	}
+}
+
+// WithMaxMemory sets how many bytes of a multipart body are kept in memory,
+// the remainder of uploaded files is stored in temporary files. The default
+// is 32 MB.
+func WithMaxMemory(n int64) SourceOption {
+	return func(o *sourceOptions) {
+		o.maxMemory = n
+	}
+}
+
+func newSourceOptions(opts []SourceOption) sourceOptions {
+	o := sourceOptions{
+		maxMemory: defaultMaxMemory,
+	}
+	for i := range opts {
+		opts[i](&o)
+	}
+	return o
+}
+
+// multiVal applies the multi value policy to all values sent for name.
+func (o sourceOptions) multiVal(name string, vs []Value) (Value, error) {
+	if len(vs) == 0 {
+		return StringVal(""), nil
+	}
+	switch o.multiValue {
+	case MultiValueFirst:
+		return vs[0], nil
+	case MultiValueLast:
+		return vs[len(vs)-1], nil
+	case MultiValueError:
+		if len(vs) > 1 {
+			return NilVal, fmt.Errorf("%s filed has %d values, only one is allowed", name, len(vs))
+		}
+		return vs[0], nil
+	case MultiValueAll:
+		// always a list, built below
+	default:
+		if len(vs) == 1 {
+			return vs[0], nil
+		}
+	}
+	list := unifiedListVal(vs)
+	return list, list.GetError()
+}
+
+// valuesVal converts url values into a map Value.
+func (o sourceOptions) valuesVal(values url.Values) Value {
+	m := make(map[string][]Value, len(values))
+	for k, vs := range values {
+		m[k] = stringVals(vs)
+	}
+	return o.multiValuesVal(m)
+}
+
+// multiValuesVal converts the values sent for each key into a map Value.
+func (o sourceOptions) multiValuesVal(values map[string][]Value) Value {
+	if len(values) == 0 {
+		return MapStringValEmpty()
+	}
+	if o.nestedKeys {
+		return o.nestedValuesVal(values)
+	}
+	m := make(map[string]Value, len(values))
+	for k, vs := range values {
+		val, err := o.multiVal(k, vs)
+		if err != nil {
+			return Value{err: err}
+		}
+		m[k] = val
+	}
+	return MapStringVal(m)
+}
+
+func stringVals(vs []string) []Value {
+	vals := make([]Value, len(vs))
+	for i := range vs {
+		vals[i] = StringVal(vs[i])
+	}
+	return vals
+}
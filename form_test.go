@@ -1,7 +1,11 @@
 package optional_test
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -96,3 +100,524 @@ func TestHttpRequestFormVal(t *testing.T) {
 		t.Errorf("Response code is %value", resp.StatusCode)
 	}
 }
+
+func TestHttpRequestBodyVal(t *testing.T) {
+	body := `{"author":"gorpher","age":24,"score":9.5,"admin":false,"email":null,
+		"tags":["go","json"],"ratios":[1,2.5],"address":{"city":"chengdu","zip":610000}}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	value := optional.HttpRequestBodyVal(r)
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]optional.Type{
+		"author":  optional.String,
+		"age":     optional.Int64,
+		"score":   optional.Float64,
+		"admin":   optional.Bool,
+		"tags":    optional.ListType(optional.String),
+		"ratios":  optional.ListType(optional.Float64),
+		"address": optional.ObjectType(map[string]optional.Type{"city": optional.String, "zip": optional.Int64}),
+	}
+	for name, want := range tests {
+		if got := value.GetMapValue(name).Type(); !got.Equals(want) {
+			t.Errorf("wrong result\nfield: %s\ngot:  %#v\nwant: %#v", name, got, want)
+		}
+	}
+	if !value.GetMapValue("email").IsNull() {
+		t.Errorf("wrong result\nfield: email\ngot:  %#v", value.GetMapValue("email"))
+	}
+
+	pvalue := value.Validates(
+		optional.Validate("author", optional.MustIsLetter()),
+		optional.Validate("age", optional.MustIsNumberValue()),
+		optional.Validate("tags", optional.MustEach(optional.MustIsLower())),
+	).Processors(
+		optional.Process("author", optional.ToUpper()),
+	).Value()
+	if err := pvalue.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	var res struct {
+		Author string
+		Age    int
+		Tags   []string
+	}
+	if err := pvalue.Aligns(
+		optional.Align("author", &res.Author),
+		optional.Align("age", &res.Age),
+		optional.Align("tags", &res.Tags),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if res.Author != "GORPHER" || res.Age != 24 || len(res.Tags) != 2 {
+		t.Errorf("wrong result\ngot:  %#v", res)
+	}
+
+	mixed := `{"mixed":[1,"a"],"empty":[],"people":[{"id":1},{"id":2.5,"name":"bob"}]}`
+	value = optional.HttpRequestBodyVal(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(mixed)))
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	tests = map[string]optional.Type{
+		"mixed": optional.ListType(optional.DynamicPseudoType),
+		"empty": optional.ListType(optional.DynamicPseudoType),
+		"people": optional.ListType(optional.ObjectTypeWithOptionalAttrs(
+			map[string]optional.Type{"id": optional.Float64, "name": optional.String}, []string{"name"})),
+	}
+	for name, want := range tests {
+		if got := value.GetMapValue(name).Type(); !got.Equals(want) {
+			t.Errorf("wrong result\nfield: %s\ngot:  %#v\nwant: %#v", name, got, want)
+		}
+	}
+
+	for _, body := range []string{"", "{", `{"a":1} {}`} {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if err := optional.HttpRequestBodyVal(r).GetError(); err == nil {
+			t.Errorf("expected error for body %q", body)
+		}
+	}
+}
+
+func TestHttpRequestQueryVal(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?ids=1&ids=2&ids=3&page=2", nil)
+
+	value := optional.HttpRequestQueryVal(r)
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	var filter struct {
+		IDs  []int
+		Page int
+	}
+	if err := value.Validates(
+		optional.Validate("ids", optional.MustEach(optional.MustIsDigit())),
+		optional.Validate("page", optional.MustIsDigit()),
+	).Value().Aligns(
+		optional.Align("ids", &filter.IDs),
+		optional.Align("page", &filter.Page),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(filter.IDs) != 3 || filter.IDs[2] != 3 || filter.Page != 2 {
+		t.Errorf("wrong result\ngot:  %#v", filter)
+	}
+
+	tests := []struct {
+		Policy optional.MultiValue
+		Want   string
+		Err    bool
+	}{
+		{optional.MultiValueAuto, "[1 2 3]", false},
+		{optional.MultiValueFirst, "1", false},
+		{optional.MultiValueLast, "3", false},
+		{optional.MultiValueAll, "[1 2 3]", false},
+		{optional.MultiValueError, "", true},
+	}
+	for _, test := range tests {
+		value := optional.HttpRequestQueryVal(r, optional.WithMultiValue(test.Policy))
+		if err := value.GetError(); (err != nil) != test.Err {
+			t.Errorf("wrong result\npolicy: %d\nerr: %v", test.Policy, err)
+			continue
+		}
+		if test.Err {
+			continue
+		}
+		if got := value.GetMapValue("ids").String(); got != test.Want {
+			t.Errorf("wrong result\npolicy: %d\ngot:  %s\nwant: %s", test.Policy, got, test.Want)
+		}
+	}
+
+	var ids []int
+	single := httptest.NewRequest(http.MethodGet, "/?ids=7", nil)
+	if err := optional.HttpRequestQueryVal(single).Aligns(optional.Align("ids", &ids)); err != nil || len(ids) != 1 {
+		t.Errorf("wrong result\ngot:  %v\nerr: %v", ids, err)
+	}
+}
+
+func TestHttpRequestFormValNested(t *testing.T) {
+	value := url.Values{}
+	value.Add("user[name]", "gorpher")
+	value.Add("user[tags][]", "a")
+	value.Add("user[tags][]", "b")
+	value.Add("user.address.city", "chengdu")
+	value.Add("items[1][price]", "5")
+	value.Add("items[0][price]", "3")
+	value.Add("page", "1")
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(value.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var req struct {
+		User struct {
+			Name    string
+			Tags    []string
+			Address *struct {
+				City string `json:"city"`
+			}
+		}
+		Items []struct {
+			Price int
+		}
+		Page int
+	}
+	values := optional.HttpRequestFormVal(r, optional.WithNestedKeys())
+	if err := values.Validates(
+		optional.Validate("items", optional.MustEach()),
+	).Value().Aligns(
+		optional.Align("user", &req.User),
+		optional.Align("items", &req.Items),
+		optional.Align("page", &req.Page),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if req.User.Name != "gorpher" || len(req.User.Tags) != 2 || req.User.Address == nil ||
+		req.User.Address.City != "chengdu" || len(req.Items) != 2 || req.Items[1].Price != 5 || req.Page != 1 {
+		t.Errorf("wrong result\ngot:  %+v", req)
+	}
+
+	flat := optional.HttpRequestFormVal(r)
+	if flat.GetMapValue("user[name]").IsNull() {
+		t.Errorf("wrong result\nflat keys must be kept: %v", flat)
+	}
+
+	conflict := httptest.NewRequest(http.MethodGet, "/?a=1&a.b=2", nil)
+	if err := optional.HttpRequestQueryVal(conflict, optional.WithNestedKeys()).GetError(); err == nil {
+		t.Error("expected error for key used as value and nested field")
+	}
+	if got := optional.HttpRequestQueryVal(conflict).GetMapValue("a.b").String(); got != "2" {
+		t.Errorf("wrong result\ngot:  %s\nwant: 2", got)
+	}
+}
+
+func TestHttpRequestMultipartVal(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("title", "avatar")
+	fw, _ := mw.CreateFormFile("avatar", "me.png")
+	fw.Write([]byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)))
+	fw, _ = mw.CreateFormFile("docs[]", "a.txt")
+	fw.Write([]byte("hello"))
+	fw, _ = mw.CreateFormFile("docs[]", "b.txt")
+	fw.Write([]byte("world"))
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	value := optional.HttpRequestMultipartVal(r, optional.WithMaxMemory(1<<20), optional.WithNestedKeys())
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if ty := value.GetMapValue("avatar").Type(); !ty.Equals(optional.File) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", ty, optional.File)
+	}
+	if err := value.Validates(
+		optional.Validate("title", optional.MustIsLetter()),
+		optional.Validate("avatar",
+			optional.MustMaxFileSize(1024),
+			optional.MustFileExt("png", ".jpg"),
+			optional.MustFileMIME("image/*")),
+		optional.Validate("docs", optional.MustEach(optional.MustFileMIME("text/plain"))),
+	).GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if err := value.GetMapValue("avatar").Validate("avatar", optional.MustMaxFileSize(8)).GetError(); err == nil {
+		t.Error("expected error for oversized file")
+	}
+	if err := value.GetMapValue("avatar").Validate("avatar", optional.MustFileMIME("text/plain")).GetError(); err == nil {
+		t.Error("expected error for wrong MIME type")
+	}
+
+	var upload struct {
+		Title  string
+		Avatar *multipart.FileHeader
+		Docs   []*multipart.FileHeader
+	}
+	if err := value.Aligns(
+		optional.Align("title", &upload.Title),
+		optional.Align("avatar", &upload.Avatar),
+		optional.Align("docs", &upload.Docs),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if upload.Avatar == nil || upload.Avatar.Filename != "me.png" || len(upload.Docs) != 2 {
+		t.Errorf("wrong result\ngot:  %+v", upload)
+	}
+}
+
+func TestHttpRequestHeaderCookiePathVal(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	r.Header.Set("X-Request-ID", "9f8a3c4e-4b1d-4f7e-8a2b-1c2d3e4f5a6b")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+
+	header := optional.HttpRequestHeaderVal(r)
+	if err := header.Validates(
+		optional.Validate("Authorization", optional.MustHasString("Bearer ")),
+		optional.Validate("X-Request-ID", optional.MustIsUUID()),
+		optional.Validate("authorization", optional.MustHasString("Bearer ")),
+	).GetError(); err != nil {
+		t.Error(err)
+	}
+	if got := header.GetMapValue("x-request-id").String(); got != "9f8a3c4e-4b1d-4f7e-8a2b-1c2d3e4f5a6b" {
+		t.Errorf("wrong result\ngot:  %s", got)
+	}
+	r.AddCookie(&http.Cookie{Name: "user.name", Value: "gorpher"})
+	if got := optional.HttpRequestCookieVal(r, optional.WithNestedKeys()).GetMapValue("user.name").String(); got != "gorpher" {
+		t.Errorf("wrong result\ngot:  %s\nwant: gorpher", got)
+	}
+
+	var session string
+	if err := optional.HttpRequestCookieVal(r).Validates(
+		optional.Validate("session", optional.MustIsLetterOrDigit()),
+	).Value().Aligns(optional.Align("session", &session)); err != nil || session != "abc123" {
+		t.Errorf("wrong result\ngot:  %s\nerr: %v", session, err)
+	}
+
+	params := func(req *http.Request) map[string]string {
+		return map[string]string{"id": strings.TrimPrefix(req.URL.Path, "/users/")}
+	}
+	var id int
+	if err := optional.HttpRequestPathVal(r, params).Validates(
+		optional.Validate("id", optional.MustIsDigit()),
+	).Value().Aligns(optional.Align("id", &id)); err != nil || id != 42 {
+		t.Errorf("wrong result\ngot:  %d\nerr: %v", id, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/users/42?page=x&id=7", strings.NewReader(`{"id":42,"name":"gorpher"}`))
+	path := optional.HttpRequestPathVal(r, func(*http.Request) map[string]string {
+		return map[string]string{"id": "42"}
+	})
+	query := optional.HttpRequestQueryVal(r)
+	body := optional.HttpRequestBodyVal(r)
+
+	merged := optional.Merge(path, query, body)
+	if err := merged.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	origins := map[string]string{"id": "path parameter", "page": "query parameter", "name": "body field"}
+	for name, want := range origins {
+		if got := merged.Origin(name); got != want {
+			t.Errorf("wrong result\nkey: %s\ngot:  %s\nwant: %s", name, got, want)
+		}
+	}
+	if got := merged.GetMapValue("id").String(); got != "42" {
+		t.Errorf("wrong result\ngot:  %s\nwant: 42", got)
+	}
+	// a value with origins must stay comparable
+	if a, b := optional.Merge(path).GetMapValue("id"), optional.StringVal("42"); a != b {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", a, b)
+	}
+
+	err := merged.Validates(optional.Validate("page", optional.MustIsDigit())).GetError()
+	if err == nil || err.Error() != "query parameter `page` filed value must is digit" {
+		t.Errorf("wrong result\ngot:  %v", err)
+	}
+
+	if err := optional.MergeStrict(path, query).GetError(); err == nil {
+		t.Error("expected conflict between path and query id")
+	}
+}
+
+func TestHttpRequestVal(t *testing.T) {
+	form := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=gorpher"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"name":"gorpher"}`))
+	body.Header.Set("Content-Type", "application/json; charset=utf-8")
+	problem := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name":"gorpher"}`))
+	problem.Header.Set("Content-Type", "application/merge-patch+json")
+	query := httptest.NewRequest(http.MethodGet, "/?name=gorpher", nil)
+
+	for _, r := range []*http.Request{form, body, problem, query} {
+		value := optional.HttpRequestVal(r)
+		if got := value.GetMapValue("name").String(); got != "gorpher" {
+			t.Errorf("wrong result\ncontent type: %s\ngot:  %s\nerr: %v", r.Header.Get("Content-Type"), got, value.GetError())
+		}
+	}
+
+	yaml := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name: gorpher"))
+	yaml.Header.Set("Content-Type", "application/yaml")
+	w := httptest.NewRecorder()
+	if err := optional.HttpRequestVal(yaml).GetErrorResponseWriter(w); err == nil {
+		t.Fatal("expected unsupported media type error")
+	}
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("wrong result\ngot:  %d\nwant: %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+
+	t.Cleanup(optional.RegisterDecoder("application/yaml", func(req *http.Request, opts ...optional.SourceOption) optional.Value {
+		return optional.MapStringVal(map[string]optional.Value{"name": optional.StringVal("gorpher")})
+	}))
+	if got := optional.HttpRequestVal(yaml).GetMapValue("name").String(); got != "gorpher" {
+		t.Errorf("wrong result\ngot:  %s", got)
+	}
+}
+
+func TestSourceLimits(t *testing.T) {
+	tests := []struct {
+		Body   string
+		Option optional.SourceOption
+		Limit  optional.LimitKind
+		Status int
+	}{
+		{`{"name":"gorpher"}`, optional.WithMaxBytes(8), optional.LimitBytes, http.StatusRequestEntityTooLarge},
+		{`{"a":{"b":{"c":1}}}`, optional.WithMaxDepth(2), optional.LimitDepth, http.StatusBadRequest},
+		{`{"a":1,"b":{"c":1,"d":2}}`, optional.WithMaxKeys(3), optional.LimitKeys, http.StatusBadRequest},
+		{`{"name":"gorpher"}`, optional.WithMaxStringLength(4), optional.LimitStringLength, http.StatusBadRequest},
+		{`{"ids":[1,2,3]}`, optional.WithMaxArrayLength(2), optional.LimitArrayLength, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.Body))
+		w := httptest.NewRecorder()
+		err := optional.HttpRequestBodyVal(r, test.Option).GetErrorResponseWriter(w)
+		le, ok := err.(*optional.LimitError)
+		if !ok || le.Limit != test.Limit || w.Code != test.Status {
+			t.Errorf("wrong result\nbody: %s\ngot:  %v (%d)\nwant: %s (%d)", test.Body, err, w.Code, test.Limit, test.Status)
+		}
+		r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.Body))
+		if err := optional.HttpRequestBodyVal(r).GetError(); err != nil {
+			t.Errorf("unexpected error without limit: %v", err)
+		}
+	}
+
+	form := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=1&b=2&c=3"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, ok := optional.HttpRequestFormVal(form, optional.WithMaxBytes(4)).GetError().(*optional.LimitError); !ok {
+		t.Error("expected byte limit error for form")
+	}
+	query := httptest.NewRequest(http.MethodGet, "/?a=1&b=2&c=3", nil)
+	if _, ok := optional.HttpRequestQueryVal(query, optional.WithMaxKeys(2)).GetError().(*optional.LimitError); !ok {
+		t.Error("expected key limit error for query")
+	}
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestSourceLimitsWhileDecoding(t *testing.T) {
+	var keys strings.Builder
+	keys.WriteString("{")
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&keys, `"k%d":%d,`, i, i)
+	}
+	keys.WriteString(`"end":0}`)
+	tests := []struct {
+		Name   string
+		Body   string
+		Decode func(io.Reader, ...optional.SourceOption) optional.Value
+		Option optional.SourceOption
+		Limit  optional.LimitKind
+	}{
+		{"json depth", strings.Repeat("[", 1<<20), optional.DecodeJSONVal, optional.WithMaxDepth(8), optional.LimitDepth},
+		{"json keys", keys.String(), optional.DecodeJSONVal, optional.WithMaxKeys(10), optional.LimitKeys},
+		{"json array", "[" + strings.Repeat("1,", 1<<20) + "1]", optional.DecodeJSONVal, optional.WithMaxArrayLength(10), optional.LimitArrayLength},
+		{"xml depth", strings.Repeat("<a>", 1<<20), optional.DecodeXMLVal, optional.WithMaxDepth(8), optional.LimitDepth},
+		{"xml keys", "<r>" + strings.Repeat("<a>1</a><b>2</b><c>3</c>", 1<<16) + "</r>", optional.DecodeXMLVal, optional.WithMaxArrayLength(10), optional.LimitArrayLength},
+	}
+	for _, test := range tests {
+		r := &countingReader{r: strings.NewReader(test.Body)}
+		err := test.Decode(r, test.Option).GetError()
+		if le, ok := err.(*optional.LimitError); !ok || le.Limit != test.Limit {
+			t.Errorf("wrong result\ntest: %s\ngot:  %v\nwant: %s", test.Name, err, test.Limit)
+		}
+		if r.n > 1<<16 {
+			t.Errorf("wrong result\ntest: %s\nread %d of %d bytes before failing", test.Name, r.n, len(test.Body))
+		}
+	}
+	if err := optional.DecodeJSONVal(strings.NewReader(strings.Repeat("[", 20000))).GetError(); err == nil {
+		t.Error("expected an error for too deeply nested json")
+	}
+	list := optional.DecodeJSONVal(strings.NewReader("[1,2.5,3]"))
+	if want := optional.ListType(optional.Float64); !list.Type().Equals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", list.Type(), want)
+	}
+
+	queryTests := []struct {
+		Query  string
+		Option optional.SourceOption
+		Limit  optional.LimitKind
+	}{
+		{"a[b][c][d]=1", optional.WithMaxDepth(3), optional.LimitDepth},
+		{"a[b]=1&a[c]=2", optional.WithMaxKeys(2), optional.LimitKeys},
+		{"id=1&id=2&id=3", optional.WithMaxArrayLength(2), optional.LimitArrayLength},
+		{"name=gorpher", optional.WithMaxStringLength(4), optional.LimitStringLength},
+	}
+	for _, test := range queryTests {
+		r := httptest.NewRequest(http.MethodGet, "/?"+test.Query, nil)
+		err := optional.HttpRequestQueryVal(r, test.Option, optional.WithNestedKeys()).GetError()
+		if le, ok := err.(*optional.LimitError); !ok || le.Limit != test.Limit {
+			t.Errorf("wrong result\nquery: %s\ngot:   %v\nwant:  %s", test.Query, err, test.Limit)
+		}
+	}
+	// list elements are no keys, a list of three values has a single key
+	r := httptest.NewRequest(http.MethodGet, "/?a[0]=1&a[1]=2&a[2]=3", nil)
+	if err := optional.HttpRequestQueryVal(r, optional.WithMaxKeys(1), optional.WithNestedKeys()).GetError(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	form := httptest.NewRequest(http.MethodPost, "/?page=1", strings.NewReader("id=1&id=2&id=3"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, ok := optional.HttpRequestFormVal(form, optional.WithMaxArrayLength(2)).GetError().(*optional.LimitError); !ok {
+		t.Error("expected array length limit error for form")
+	}
+}
+
+func TestHttpRequestXMLVal(t *testing.T) {
+	body := `<xml>
+	<return_code><![CDATA[SUCCESS]]></return_code>
+	<total_fee>888</total_fee>
+	<coupon id="c1">10</coupon>
+	<item><name>a</name></item>
+	<item><name>b</name></item>
+</xml>`
+	r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	r.Header.Set("Content-Type", "text/xml; charset=utf-8")
+
+	value := optional.HttpRequestVal(r)
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	var notify struct {
+		ReturnCode string `json:"return_code"`
+		TotalFee   int    `json:"total_fee"`
+		Coupon     struct {
+			ID    string `json:"@id"`
+			Value int    `json:"#text"`
+		}
+		Item []struct {
+			Name string
+		}
+	}
+	if err := value.Validates(
+		optional.Validate("return_code", optional.MustIsUpper()),
+		optional.Validate("total_fee", optional.MustIsNumberValue()),
+	).Value().Aligns(
+		optional.Align("return_code", &notify.ReturnCode),
+		optional.Align("total_fee", &notify.TotalFee),
+		optional.Align("coupon", &notify.Coupon),
+		optional.Align("item", &notify.Item),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if notify.ReturnCode != "SUCCESS" || notify.TotalFee != 888 || notify.Coupon.ID != "c1" ||
+		notify.Coupon.Value != 10 || len(notify.Item) != 2 || notify.Item[1].Name != "b" {
+		t.Errorf("wrong result\ngot:  %+v", notify)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	if err := optional.HttpRequestXMLVal(r, optional.WithMaxBytes(16)).GetError(); err == nil {
+		t.Error("expected byte limit error")
+	}
+}
@@ -0,0 +1,204 @@
+package optional
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DecodeJSONVal reads a single JSON document from r and returns it as a
+// Value. Objects become object values, arrays become list values, strings,
+// numbers and booleans become the matching primitive values.
+//
+// Numbers that are integers become Int64 values, all others become Float64
+// values. The elements of an array are converted to their unified type, see
+// Unify, so an array mixing the two is widened to a list of Float64. An
+// array whose elements have no common type, as well as an empty array, is a
+// list of DynamicPseudoType, and null is a null of DynamicPseudoType.
+//
+// The limit options, such as WithMaxBytes, are applied to the document as it
+// is read, decoding stops at the first value exceeding one of them.
+func DecodeJSONVal(r io.Reader, opts ...SourceOption) Value {
+	o := newSourceOptions(opts)
+	dec := json.NewDecoder(o.limitReader(r))
+	dec.UseNumber()
+	d := &jsonDecoder{dec: dec, o: o}
+	val, err := d.value(1)
+	if err != nil {
+		if err == io.EOF {
+			return Value{err: errors.New("没有期望的请求参数和值")}
+		}
+		return Value{err: limitError(err)}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		var le *LimitError
+		if errors.As(err, &le) {
+			return Value{err: le}
+		}
+		return Value{err: errors.New("invalid data after top-level json value")}
+	}
+	return val
+}
+
+// maxJSONDepth is the nesting depth json.Unmarshal allows, it applies when
+// WithMaxDepth is not given.
+const maxJSONDepth = 10000
+
+// jsonDecoder builds a Value from the tokens of a json.Decoder, checking the
+// limits of the source options on every token.
+type jsonDecoder struct {
+	dec  *json.Decoder
+	o    sourceOptions
+	keys int
+}
+
+func (d *jsonDecoder) value(depth int) (Value, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return NilVal, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if d.o.maxDepth > 0 && depth > d.o.maxDepth {
+			return NilVal, &LimitError{Limit: LimitDepth, Max: int64(d.o.maxDepth)}
+		}
+		if depth > maxJSONDepth {
+			return NilVal, errors.New("exceeded max depth")
+		}
+		if t == '{' {
+			return d.object(depth)
+		}
+		return d.list(depth)
+	case string:
+		if d.o.maxStringLength > 0 && len(t) > d.o.maxStringLength {
+			return NilVal, &LimitError{Limit: LimitStringLength, Max: int64(d.o.maxStringLength)}
+		}
+		return StringVal(t), nil
+	case json.Number:
+		return jsonNumberVal(t)
+	case bool:
+		return BoolVal(t), nil
+	case nil:
+		// null carries no type of its own.
+		return NullVal(DynamicPseudoType), nil
+	default:
+		return NilVal, fmt.Errorf("unsupported json value %T", tok)
+	}
+}
+
+func (d *jsonDecoder) object(depth int) (Value, error) {
+	attrs := map[string]Value{}
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return NilVal, unexpectedEOF(err)
+		}
+		key, _ := tok.(string)
+		if _, ok := attrs[key]; !ok {
+			d.keys++
+			if d.o.maxKeys > 0 && d.keys > d.o.maxKeys {
+				return NilVal, &LimitError{Limit: LimitKeys, Max: int64(d.o.maxKeys)}
+			}
+		}
+		attr, err := d.value(depth + 1)
+		if err != nil {
+			return NilVal, unexpectedEOF(err)
+		}
+		attrs[key] = attr
+	}
+	if _, err := d.dec.Token(); err != nil {
+		return NilVal, unexpectedEOF(err)
+	}
+	val := ObjectVal(attrs)
+	return val, val.GetError()
+}
+
+func (d *jsonDecoder) list(depth int) (Value, error) {
+	var elems []Value
+	for d.dec.More() {
+		if d.o.maxArrayLength > 0 && len(elems) == d.o.maxArrayLength {
+			return NilVal, &LimitError{Limit: LimitArrayLength, Max: int64(d.o.maxArrayLength)}
+		}
+		elem, err := d.value(depth + 1)
+		if err != nil {
+			return NilVal, unexpectedEOF(err)
+		}
+		elems = append(elems, elem)
+	}
+	if _, err := d.dec.Token(); err != nil {
+		return NilVal, unexpectedEOF(err)
+	}
+	if len(elems) == 0 {
+		// as with null, an empty array has no element type to go by.
+		return ListValEmpty(DynamicPseudoType), nil
+	}
+	list := unifiedListVal(elems)
+	return list, list.GetError()
+}
+
+// unexpectedEOF reports the end of the input within an object or array as
+// an error, rather than as the end of an empty input.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func jsonVal(raw interface{}) (Value, error) {
+	switch v := raw.(type) {
+	case nil:
+		// null carries no type of its own.
+		return NullVal(DynamicPseudoType), nil
+	case bool:
+		return BoolVal(v), nil
+	case string:
+		return StringVal(v), nil
+	case json.Number:
+		return jsonNumberVal(v)
+	case []interface{}:
+		return jsonListVal(v)
+	case map[string]interface{}:
+		attrs := make(map[string]Value, len(v))
+		for k := range v {
+			attr, err := jsonVal(v[k])
+			if err != nil {
+				return NilVal, err
+			}
+			attrs[k] = attr
+		}
+		return ObjectVal(attrs), nil
+	default:
+		return NilVal, fmt.Errorf("unsupported json value %T", raw)
+	}
+}
+
+func jsonNumberVal(n json.Number) (Value, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return Int64Val(i), nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return NilVal, fmt.Errorf("a number is required")
+	}
+	return Float64Val(f), nil
+}
+
+func jsonListVal(raw []interface{}) (Value, error) {
+	if len(raw) == 0 {
+		// as with null, an empty array has no element type to go by.
+		return ListValEmpty(DynamicPseudoType), nil
+	}
+	elems := make([]Value, len(raw))
+	for i := range raw {
+		var err error
+		elems[i], err = jsonVal(raw[i])
+		if err != nil {
+			return NilVal, err
+		}
+	}
+	list := unifiedListVal(elems)
+	return list, list.GetError()
+}
@@ -0,0 +1,183 @@
+package optional
+
+import "fmt"
+
+const (
+	sourceQuery  = "query parameter"
+	sourceForm   = "form field"
+	sourceBody   = "body field"
+	sourceHeader = "header"
+	sourceCookie = "cookie"
+	sourcePath   = "path parameter"
+)
+
+// originSet holds the source of each key of a merged value. Value keeps it
+// behind a pointer so that it stays comparable.
+type originSet struct {
+	m map[string]string
+}
+
+func (s *originSet) lookup(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	origin, ok := s.m[name]
+	return origin, ok
+}
+
+// withSource records where the keys of a source value came from.
+func (val Value) withSource(src string) Value {
+	val.src = src
+	return val
+}
+
+// Origin returns the source the named key of a map value came from, such as
+// "query parameter". For a value built by Merge it is the source that won
+// the key. It returns an empty string if the source is unknown.
+func (val Value) Origin(name string) string {
+	if origin, ok := val.origins.lookup(name); ok {
+		return origin
+	}
+	return val.src
+}
+
+func fieldName(source, name string) string {
+	if source == "" {
+		return name
+	}
+	return source + " `" + name + "`"
+}
+
+// Merge combines the keys of several map or object values, such as the
+// values of different request sources, into one map Value. The values are
+// given in order of precedence: when a key is present in more than one of
+// them, the first one wins. The merged value remembers which source each key
+// came from, see Origin.
+func Merge(vals ...Value) Value {
+	return merge(false, vals)
+}
+
+// MergeStrict is like Merge, but fails when a key is present in more than
+// one value with different values.
+func MergeStrict(vals ...Value) Value {
+	return merge(true, vals)
+}
+
+func merge(strict bool, vals []Value) Value {
+	m := map[string]Value{}
+	origins := map[string]string{}
+	for i := range vals {
+		if err := vals[i].GetError(); err != nil {
+			return Value{err: err}
+		}
+		if !vals[i].IsMapValue() && !vals[i].IsObjectValue() {
+			return Value{err: fmt.Errorf("merge value %d is not a map value", i)}
+		}
+		for _, name := range vals[i].attrNames() {
+			val := vals[i].GetMapValue(name)
+			if val.IsNull() {
+				continue
+			}
+			if prev, ok := m[name]; ok {
+				if strict && !prev.Equals(val) {
+					return Value{err: fmt.Errorf("%s conflicts with %s",
+						fieldName(origins[name], name), fieldName(vals[i].Origin(name), name))}
+				}
+				continue
+			}
+			m[name] = val
+			origins[name] = vals[i].Origin(name)
+		}
+	}
+	if len(m) == 0 {
+		return MapStringValEmpty()
+	}
+	merged := MapStringVal(m)
+	merged.origins = &originSet{m: origins}
+	return merged
+}
+
+// Origins returns the source of every key of a value built by Merge or
+// DeepMerge, keyed by dotted path such as db.host.
+func (val Value) Origins() map[string]string {
+	origins := map[string]string{}
+	if val.origins != nil {
+		for k, v := range val.origins.m {
+			origins[k] = v
+		}
+	}
+	return origins
+}
+
+// DeepMerge is like Merge, but nested map and object values present in more
+// than one value are merged key by key instead of being taken as a whole.
+// Lists and other values are taken as a whole from the value with the
+// highest precedence. Origin and Origins of the merged value report the
+// source of every key down to the leaves, named by dotted path such as
+// db.host.
+func DeepMerge(vals ...Value) Value {
+	for i := range vals {
+		if err := vals[i].GetError(); err != nil {
+			return Value{err: err}
+		}
+		if !vals[i].IsMapValue() && !vals[i].IsObjectValue() {
+			return Value{err: fmt.Errorf("merge value %d is not a map value", i)}
+		}
+	}
+	origins := map[string]string{}
+	m := deepMergeAttrs(vals, vals, "", origins)
+	if len(m) == 0 {
+		return MapStringValEmpty()
+	}
+	merged := MapStringVal(m)
+	merged.origins = &originSet{m: origins}
+	return merged
+}
+
+// deepMergeAttrs merges the attributes of vals, all of which are map or
+// object values found at path prefix of the corresponding roots.
+func deepMergeAttrs(vals, roots []Value, prefix string, origins map[string]string) map[string]Value {
+	var names []string
+	seen := map[string]bool{}
+	for i := range vals {
+		for _, name := range vals[i].attrNames() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	m := make(map[string]Value, len(names))
+	for _, name := range names {
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		var parts, partRoots []Value
+		for i := range vals {
+			attr := vals[i].GetMapValue(name)
+			if attr.IsNull() {
+				continue
+			}
+			if len(parts) > 0 && !(attr.IsMapValue() || attr.IsObjectValue()) {
+				// a value below a nested one is overridden by it
+				break
+			}
+			parts = append(parts, attr)
+			partRoots = append(partRoots, roots[i])
+			if !(attr.IsMapValue() || attr.IsObjectValue()) {
+				break
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if parts[0].IsMapValue() || parts[0].IsObjectValue() {
+			m[name] = ObjectVal(deepMergeAttrs(parts, partRoots, path, origins))
+			continue
+		}
+		m[name] = parts[0]
+		origins[path] = partRoots[0].Origin(path)
+	}
+	return m
+}
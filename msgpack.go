@@ -0,0 +1,663 @@
+package optional
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MarshalMsgpack encodes the value as MessagePack guided by the given type,
+// the value is converted to it first, see converter.To. Like JSON the
+// encoding does not carry the type, decode it with UnmarshalMsgpack and the
+// same type.
+//
+// Where the type is DynamicPseudoType, such as for the elements of a list
+// of DynamicPseudoType or the keys of StringMap(), the value is written
+// along with its type as a two element array of the JSON of the type and
+// the value, so any value can be encoded with DynamicPseudoType.
+//
+// Times are written with the timestamp extension type and read back in UTC,
+// durations as nanoseconds and big numbers and decimals as strings to keep
+// every digit.
+func MarshalMsgpack(val Value, ty Type) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := marshalMsgpack(&buf, val, ty); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalMsgpack(buf *bytes.Buffer, val Value, ty Type) error {
+	if err := val.GetError(); err != nil {
+		return err
+	}
+	if ty.IsDynamicType() {
+		if val.ty.IsDynamicType() || val.ty.typeImpl == nil {
+			// a null of unknown type
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		tyJSON, err := json.Marshal(val.ty)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0x92)
+		writeMsgpackBin(buf, tyJSON)
+		return marshalMsgpack(buf, val, val.ty)
+	}
+	val, err := val.Converter().To(ty)
+	if err != nil {
+		return err
+	}
+	if val.IsNull() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	switch {
+	case ty.IsListType():
+		elems, _ := val.v.([]Value)
+		writeMsgpackLen(buf, len(elems), 0x90, 0xdc)
+		for i := range elems {
+			if err := marshalMsgpack(buf, elems[i], ty.ElementType()); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return nil
+	case ty.IsMapType(), ty.IsObjectType():
+		names := val.attrNames()
+		sort.Strings(names)
+		writeMsgpackLen(buf, len(names), 0x80, 0xde)
+		for _, name := range names {
+			attrType := DynamicPseudoType
+			if ty.IsObjectType() {
+				attrType = ty.AttributeType(name)
+			} else if t := ty.typeImpl.(typeStringMap).GetStringMapType(name); t.typeImpl != nil {
+				attrType = t
+			}
+			writeMsgpackStr(buf, name)
+			if err := marshalMsgpack(buf, val.GetMapValue(name), attrType); err != nil {
+				return fmt.Errorf("attribute %q: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	switch v := val.v.(type) {
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case string:
+		writeMsgpackStr(buf, v)
+	case []byte:
+		writeMsgpackBin(buf, v)
+	case int:
+		writeMsgpackInt(buf, int64(v))
+	case int8:
+		writeMsgpackInt(buf, int64(v))
+	case int16:
+		writeMsgpackInt(buf, int64(v))
+	case int32:
+		writeMsgpackInt(buf, int64(v))
+	case int64:
+		writeMsgpackInt(buf, v)
+	case time.Duration:
+		writeMsgpackInt(buf, int64(v))
+	case uint:
+		writeMsgpackUint(buf, uint64(v))
+	case uint8:
+		writeMsgpackUint(buf, uint64(v))
+	case uint16:
+		writeMsgpackUint(buf, uint64(v))
+	case uint32:
+		writeMsgpackUint(buf, uint64(v))
+	case uint64:
+		writeMsgpackUint(buf, v)
+	case float32:
+		buf.WriteByte(0xca)
+		writeMsgpackBE(buf, uint64(math.Float32bits(v)), 4)
+	case float64:
+		buf.WriteByte(0xcb)
+		writeMsgpackBE(buf, math.Float64bits(v), 8)
+	case time.Time:
+		writeMsgpackTime(buf, v)
+	case *big.Float, *big.Int:
+		s, err := val.Converter().String()
+		if err != nil {
+			return err
+		}
+		writeMsgpackStr(buf, s)
+	default:
+		return fmt.Errorf("unsupported msgpack value of type %s", ty.FriendlyName())
+	}
+	return nil
+}
+
+// writeMsgpackLen writes the header of an array or a map, fix is the
+// header of the fixed length form and long of the 16 bit form.
+func writeMsgpackLen(buf *bytes.Buffer, n int, fix, long byte) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(long)
+		writeMsgpackBE(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(long + 1)
+		writeMsgpackBE(buf, uint64(n), 4)
+	}
+}
+
+func writeMsgpackStr(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		writeMsgpackBE(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xdb)
+		writeMsgpackBE(buf, uint64(n), 4)
+	}
+	buf.WriteString(s)
+}
+
+func writeMsgpackBin(buf *bytes.Buffer, b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		writeMsgpackBE(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xc6)
+		writeMsgpackBE(buf, uint64(n), 4)
+	}
+	buf.Write(b)
+}
+
+// writeMsgpackBE writes the low n bytes of u in big endian order.
+func writeMsgpackBE(buf *bytes.Buffer, u uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		buf.WriteByte(byte(u >> (8 * uint(i))))
+	}
+}
+
+// writeMsgpackInt writes i in the shortest integer form.
+func writeMsgpackInt(buf *bytes.Buffer, i int64) {
+	if i >= 0 {
+		writeMsgpackUint(buf, uint64(i))
+		return
+	}
+	switch {
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		writeMsgpackBE(buf, uint64(int16(i)), 2)
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		writeMsgpackBE(buf, uint64(int32(i)), 4)
+	default:
+		buf.WriteByte(0xd3)
+		writeMsgpackBE(buf, uint64(i), 8)
+	}
+}
+
+// writeMsgpackUint writes u in the shortest integer form.
+func writeMsgpackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		writeMsgpackBE(buf, u, 2)
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		writeMsgpackBE(buf, u, 4)
+	default:
+		buf.WriteByte(0xcf)
+		writeMsgpackBE(buf, u, 8)
+	}
+}
+
+// writeMsgpackTime writes t with the timestamp extension type -1 in its
+// shortest form.
+func writeMsgpackTime(buf *bytes.Buffer, t time.Time) {
+	sec, nsec := t.Unix(), uint32(t.Nanosecond())
+	switch {
+	case sec>>34 != 0:
+		buf.Write([]byte{0xc7, 12, 0xff})
+		writeMsgpackBE(buf, uint64(nsec), 4)
+		writeMsgpackBE(buf, uint64(sec), 8)
+	case nsec == 0 && sec <= math.MaxUint32:
+		buf.Write([]byte{0xd6, 0xff})
+		writeMsgpackBE(buf, uint64(sec), 4)
+	default:
+		buf.Write([]byte{0xd7, 0xff})
+		writeMsgpackBE(buf, uint64(nsec)<<34|uint64(sec), 8)
+	}
+}
+
+// UnmarshalMsgpack decodes MessagePack written by MarshalMsgpack into a
+// value of the given type. Integers may be read into any integer, float,
+// big number or decimal type they fit.
+func UnmarshalMsgpack(data []byte, ty Type) (Value, error) {
+	if ty.typeImpl == nil {
+		return NilVal, errors.New("can not unmarshal into an invalid type")
+	}
+	r := &msgpackReader{b: data}
+	val, err := r.value(ty)
+	if err != nil {
+		return NilVal, err
+	}
+	if r.pos != len(r.b) {
+		return NilVal, errors.New("invalid data after top-level msgpack value")
+	}
+	return val, nil
+}
+
+var errMsgpackShort = errors.New("unexpected end of msgpack data")
+
+type msgpackReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.b)-r.pos < n {
+		return nil, errMsgpackShort
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// uint reads a big endian unsigned integer of n bytes.
+func (r *msgpackReader) uint(n int) (uint64, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (r *msgpackReader) value(ty Type) (Value, error) {
+	if r.pos < len(r.b) && r.b[r.pos] == 0xc0 {
+		r.pos++
+		return NullVal(ty), nil
+	}
+	switch {
+	case ty.IsDynamicType():
+		return r.dynamic()
+	case ty.IsListType():
+		n, err := r.len(0x90, 0xdc, "array", ty)
+		if err != nil {
+			return NilVal, err
+		}
+		elems := make([]Value, 0, n)
+		for i := 0; i < n; i++ {
+			elem, err := r.value(ty.ElementType())
+			if err != nil {
+				return NilVal, fmt.Errorf("element %d: %w", i, err)
+			}
+			elems = append(elems, elem)
+		}
+		return Value{ty: ty, v: elems}, nil
+	case ty.IsMapType():
+		return r.stringMap(ty)
+	case ty.IsObjectType():
+		return r.object(ty)
+	}
+	return r.primitive(ty)
+}
+
+// dynamic reads a value written along with its type.
+func (r *msgpackReader) dynamic() (Value, error) {
+	n, err := r.len(0x90, 0xdc, "array", DynamicPseudoType)
+	if err != nil {
+		return NilVal, err
+	}
+	if n != 2 {
+		return NilVal, errors.New("a dynamic value must be an array of the type and the value")
+	}
+	tyJSON, err := r.bytes(DynamicPseudoType)
+	if err != nil {
+		return NilVal, err
+	}
+	var ty Type
+	if err := json.Unmarshal(tyJSON, &ty); err != nil {
+		return NilVal, err
+	}
+	if ty.IsDynamicType() {
+		return NilVal, errors.New("a dynamic value must have a known type")
+	}
+	return r.value(ty)
+}
+
+func (r *msgpackReader) stringMap(ty Type) (Value, error) {
+	n, err := r.len(0x80, 0xde, "map", ty)
+	if err != nil {
+		return NilVal, err
+	}
+	impl := ty.typeImpl.(typeStringMap)
+	rawMap := make(map[string]interface{}, n)
+	types := make(map[string]Type, n)
+	for i := 0; i < n; i++ {
+		name, err := r.key()
+		if err != nil {
+			return NilVal, err
+		}
+		attrType := impl.GetStringMapType(name)
+		if attrType.typeImpl == nil {
+			attrType = DynamicPseudoType
+		}
+		attr, err := r.value(attrType)
+		if err != nil {
+			return NilVal, fmt.Errorf("attribute %q: %w", name, err)
+		}
+		rawMap[name] = attr.v
+		types[name] = attr.ty
+	}
+	return Value{ty: Type{typeStringMap{AttrType: types, ElemType: impl.ElemType}}, v: rawMap}, nil
+}
+
+func (r *msgpackReader) object(ty Type) (Value, error) {
+	n, err := r.len(0x80, 0xde, "map", ty)
+	if err != nil {
+		return NilVal, err
+	}
+	res := make(map[string]Value, n)
+	for i := 0; i < n; i++ {
+		name, err := r.key()
+		if err != nil {
+			return NilVal, err
+		}
+		if !ty.HasAttribute(name) {
+			return NilVal, fmt.Errorf("unexpected attribute %q", name)
+		}
+		attr, err := r.value(ty.AttributeType(name))
+		if err != nil {
+			return NilVal, fmt.Errorf("attribute %q: %w", name, err)
+		}
+		res[name] = attr
+	}
+	for name := range ty.AttributeTypes() {
+		if _, ok := res[name]; !ok && !ty.AttributeOptional(name) {
+			return NilVal, fmt.Errorf("attribute %q is required", name)
+		}
+	}
+	return Value{ty: ty, v: res}, nil
+}
+
+func (r *msgpackReader) key() (string, error) {
+	b, err := r.str(String)
+	if err != nil {
+		return "", fmt.Errorf("map key: %w", err)
+	}
+	return string(b), nil
+}
+
+// len reads the header of an array or a map.
+func (r *msgpackReader) len(fix, long byte, kind string, ty Type) (int, error) {
+	c, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	switch {
+	case c&0xf0 == fix:
+		n = uint64(c & 0x0f)
+	case c == long:
+		n, err = r.uint(2)
+	case c == long+1:
+		n, err = r.uint(4)
+	default:
+		return 0, msgpackTypeError(c, ty)
+	}
+	if err != nil {
+		return 0, err
+	}
+	// every element takes at least one byte
+	if n > uint64(len(r.b)-r.pos) {
+		return 0, errMsgpackShort
+	}
+	return int(n), nil
+}
+
+func (r *msgpackReader) str(ty Type) ([]byte, error) {
+	c, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	var n uint64
+	switch {
+	case c&0xe0 == 0xa0:
+		n = uint64(c & 0x1f)
+	case c == 0xd9:
+		n, err = r.uint(1)
+	case c == 0xda:
+		n, err = r.uint(2)
+	case c == 0xdb:
+		n, err = r.uint(4)
+	default:
+		r.pos--
+		return nil, msgpackTypeError(c, ty)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.next(int(n))
+}
+
+// bytes reads a bin or a str.
+func (r *msgpackReader) bytes(ty Type) ([]byte, error) {
+	c, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	var n uint64
+	switch c {
+	case 0xc4:
+		n, err = r.uint(1)
+	case 0xc5:
+		n, err = r.uint(2)
+	case 0xc6:
+		n, err = r.uint(4)
+	default:
+		r.pos--
+		return r.str(ty)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.next(int(n))
+}
+
+// number reads an integer or a float. The integer is returned as text, so
+// that it can be parsed into any integer type with its range checked.
+func (r *msgpackReader) number(ty Type) (text string, f float64, isFloat bool, err error) {
+	c, err := r.byte()
+	if err != nil {
+		return "", 0, false, err
+	}
+	var u uint64
+	switch {
+	case c <= 0x7f:
+		return strconv.Itoa(int(c)), 0, false, nil
+	case c >= 0xe0:
+		return strconv.Itoa(int(int8(c))), 0, false, nil
+	case c == 0xca:
+		u, err = r.uint(4)
+		return "", float64(math.Float32frombits(uint32(u))), true, err
+	case c == 0xcb:
+		u, err = r.uint(8)
+		return "", math.Float64frombits(u), true, err
+	case c >= 0xcc && c <= 0xcf:
+		u, err = r.uint(1 << (c - 0xcc))
+		return strconv.FormatUint(u, 10), 0, false, err
+	case c >= 0xd0 && c <= 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err = r.uint(size)
+		// sign extend
+		shift := 64 - 8*uint(size)
+		return strconv.FormatInt(int64(u<<shift)>>shift, 10), 0, false, err
+	}
+	r.pos--
+	return "", 0, false, msgpackTypeError(c, ty)
+}
+
+func (r *msgpackReader) primitive(ty Type) (Value, error) {
+	switch ty {
+	case Bool:
+		c, err := r.byte()
+		if err != nil {
+			return NilVal, err
+		}
+		if c != 0xc2 && c != 0xc3 {
+			return NilVal, msgpackTypeError(c, ty)
+		}
+		return BoolVal(c == 0xc3), nil
+	case String:
+		b, err := r.str(ty)
+		if err != nil {
+			return NilVal, err
+		}
+		return StringVal(string(b)), nil
+	case Bytes:
+		b, err := r.bytes(ty)
+		if err != nil {
+			return NilVal, err
+		}
+		return BytesVal(append([]byte{}, b...)), nil
+	case Time:
+		return r.time()
+	case Duration:
+		text, _, isFloat, err := r.number(ty)
+		if err != nil {
+			return NilVal, err
+		}
+		n, perr := strconv.ParseInt(text, 10, 64)
+		if isFloat || perr != nil {
+			return NilVal, fmt.Errorf("a duration is required")
+		}
+		return DurationVal(time.Duration(n)), nil
+	}
+
+	if ty == BigNumber || ty.IsDecimalType() {
+		if r.pos < len(r.b) && (r.b[r.pos]&0xe0 == 0xa0 || (r.b[r.pos] >= 0xd9 && r.b[r.pos] <= 0xdb)) {
+			b, err := r.str(ty)
+			if err != nil {
+				return NilVal, err
+			}
+			if ty == BigNumber {
+				return ParseBigNumberVal(string(b))
+			}
+			return ParseDecimalVal(string(b), ty.DecimalScale(), big.ToNearestEven)
+		}
+	}
+	if !ty.IsPrimitiveType() {
+		return NilVal, fmt.Errorf("unsupported msgpack value of type %s", ty.FriendlyName())
+	}
+	text, f, isFloat, err := r.number(ty)
+	if err != nil {
+		return NilVal, err
+	}
+	if isFloat {
+		val := Float64Val(f)
+		if ty == Float32 {
+			val = Float32Val(float32(f))
+		}
+		return val.Converter().To(ty)
+	}
+	switch {
+	case ty == Float32 || ty == Float64 || ty == BigNumber:
+		val, err := ParseBigNumberVal(text)
+		if err != nil {
+			return NilVal, err
+		}
+		return val.Converter().To(ty)
+	case ty.IsDecimalType():
+		return ParseDecimalVal(text, ty.DecimalScale(), big.ToNearestEven)
+	}
+	return parseIntegerVal(text, ty)
+}
+
+func (r *msgpackReader) time() (Value, error) {
+	c, err := r.byte()
+	if err != nil {
+		return NilVal, err
+	}
+	var size uint64
+	switch c {
+	case 0xd6:
+		size = 4
+	case 0xd7:
+		size = 8
+	case 0xc7:
+		if size, err = r.uint(1); err != nil {
+			return NilVal, err
+		}
+	default:
+		r.pos--
+		return NilVal, msgpackTypeError(c, Time)
+	}
+	if ext, err := r.byte(); err != nil || ext != 0xff {
+		return NilVal, fmt.Errorf("a msgpack timestamp is required")
+	}
+	var sec, nsec uint64
+	switch size {
+	case 4:
+		sec, err = r.uint(4)
+	case 8:
+		var u uint64
+		u, err = r.uint(8)
+		sec, nsec = u&(1<<34-1), u>>34
+	case 12:
+		if nsec, err = r.uint(4); err == nil {
+			sec, err = r.uint(8)
+		}
+	default:
+		return NilVal, fmt.Errorf("a msgpack timestamp is required")
+	}
+	if err != nil {
+		return NilVal, err
+	}
+	return TimeVal(time.Unix(int64(sec), int64(nsec)).UTC()), nil
+}
+
+func msgpackTypeError(c byte, ty Type) error {
+	return fmt.Errorf("msgpack value 0x%02x can not be a value of type %s", c, ty.FriendlyName())
+}
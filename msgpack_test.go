@@ -0,0 +1,180 @@
+package optional_test
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gorpher/optional/v2"
+)
+
+func TestMsgpack(t *testing.T) {
+	at := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	price, _ := optional.ParseDecimalVal("19.90", 2, big.ToNearestEven)
+	num, _ := optional.ParseBigNumberVal("1.00000000000000000000000000001")
+	user := optional.ObjectVal(map[string]optional.Value{
+		"id":   optional.Int64Val(7),
+		"name": optional.StringVal("gorpher"),
+		"tags": optional.ListVal([]optional.Value{optional.StringVal("go"), optional.StringVal("rpc")}),
+	})
+	tests := []struct {
+		Value optional.Value
+		Type  optional.Type
+		Want  []byte
+	}{
+		{optional.BoolVal(true), optional.Bool, []byte{0xc3}},
+		{optional.StringVal("go"), optional.String, []byte{0xa2, 'g', 'o'}},
+		{optional.IntVal(-1), optional.Int, []byte{0xff}},
+		{optional.Int16Val(-200), optional.Int16, []byte{0xd1, 0xff, 0x38}},
+		{optional.Int64Val(300), optional.Int64, []byte{0xcd, 0x01, 0x2c}},
+		{optional.Uint64Val(math.MaxUint64), optional.Uint64, []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{optional.Float32Val(1.5), optional.Float32, []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}},
+		{optional.Float64Val(0.1), optional.Float64, nil},
+		{optional.BytesVal([]byte{1, 2}), optional.Bytes, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{optional.TimeVal(at), optional.Time, []byte{0xd6, 0xff, 0x66, 0x31, 0xfd, 0x88}},
+		{optional.TimeVal(at.Add(500)), optional.Time, nil},
+		{optional.TimeVal(time.Date(2600, 1, 1, 0, 0, 0, 0, time.UTC)), optional.Time, nil},
+		{optional.TimeVal(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)), optional.Time, nil},
+		{optional.DurationVal(-time.Second), optional.Duration, nil},
+		{price, optional.DecimalType(2), nil},
+		{num, optional.BigNumber, nil},
+		{optional.PositiveInfinity, optional.BigNumber, nil},
+		{optional.NullVal(optional.String), optional.String, []byte{0xc0}},
+		{optional.ListValEmpty(optional.Int64), optional.ListType(optional.Int64), []byte{0x90}},
+		{user, user.Type(), nil},
+		{optional.ListVal([]optional.Value{user, user}), optional.ListType(user.Type()), nil},
+		{optional.MapStringVal(map[string]optional.Value{"a": optional.IntVal(1)}), optional.StringMapOf(optional.Int), []byte{0x81, 0xa1, 'a', 0x01}},
+		{optional.MapStringVal(map[string]optional.Value{"a": optional.IntVal(1), "b": optional.TimeVal(at)}), optional.StringMap(), nil},
+	}
+	for _, test := range tests {
+		b, err := optional.MarshalMsgpack(test.Value, test.Type)
+		if err != nil || (test.Want != nil && !bytes.Equal(b, test.Want)) {
+			t.Errorf("wrong result\ngot:  % x %v\nwant: % x", b, err, test.Want)
+			continue
+		}
+		got, err := optional.UnmarshalMsgpack(b, test.Type)
+		if err != nil {
+			t.Errorf("wrong result\ninput: % x\nerr:   %v", b, err)
+			continue
+		}
+		gotJSON, _ := got.Converter().JSON()
+		wantJSON, _ := test.Value.Converter().JSON()
+		if !got.Type().Equals(test.Type) || !bytes.Equal(gotJSON, wantJSON) {
+			t.Errorf("wrong result\ngot:  %s\nwant: %s", gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestMsgpackDynamic(t *testing.T) {
+	vals := []optional.Value{
+		optional.StringVal("a"),
+		optional.Int64Val(1),
+		optional.ListVal([]optional.Value{optional.TimeVal(time.Unix(0, 0).UTC())}),
+		optional.NullVal(optional.Bool),
+		optional.NullVal(optional.DynamicPseudoType),
+	}
+	for _, val := range vals {
+		b, err := optional.MarshalMsgpack(val, optional.DynamicPseudoType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := optional.UnmarshalMsgpack(b, optional.DynamicPseudoType)
+		if err != nil || !got.Equals(val) {
+			t.Errorf("wrong result\ngot:  %#v %v\nwant: %#v", got, err, val)
+		}
+	}
+
+	list := optional.ListVal([]optional.Value{optional.IntVal(1), optional.IntVal(2)})
+	b, _ := optional.MarshalMsgpack(list, optional.ListType(optional.Int))
+	if got, err := optional.UnmarshalMsgpack(b, optional.ListType(optional.Float64)); err != nil ||
+		!got.Equals(optional.ListVal([]optional.Value{optional.Float64Val(1), optional.Float64Val(2)})) {
+		t.Errorf("wrong result\ngot:  %#v %v", got, err)
+	}
+
+	schema := optional.ObjectTypeWithOptionalAttrs(map[string]optional.Type{
+		"id":    optional.Int64,
+		"email": optional.String,
+	}, []string{"email"})
+	errTests := []struct {
+		Input []byte
+		Type  optional.Type
+		Want  string
+	}{
+		{[]byte{0xcd, 0x01, 0x2c}, optional.Uint8, "uint8 is required"},
+		{[]byte{0xa1, 'a'}, optional.Int64, "msgpack value 0xa1 can not be a value of type int64"},
+		{[]byte{0x92, 0x01}, optional.ListType(optional.Int), "unexpected end of msgpack data"},
+		{[]byte{0x80}, schema, `attribute "id" is required`},
+		{[]byte{0x81, 0xa1, 'x', 0x01}, schema, `unexpected attribute "x"`},
+		{[]byte{0x01, 0x02}, optional.Int, "invalid data after top-level msgpack value"},
+		{[]byte{0x01}, optional.DynamicPseudoType, "msgpack value 0x01 can not be a value of type dynamic"},
+	}
+	for _, test := range errTests {
+		_, err := optional.UnmarshalMsgpack(test.Input, test.Type)
+		if err == nil || err.Error() != test.Want {
+			t.Errorf("wrong result\ninput: % x\ngot:   %v\nwant:  %s", test.Input, err, test.Want)
+		}
+	}
+	if _, err := optional.MarshalMsgpack(optional.StringVal("x"), optional.Int64); err == nil {
+		t.Error("expected a conversion error")
+	}
+}
+
+func benchmarkValue() optional.Value {
+	orders := make([]optional.Value, 20)
+	for i := range orders {
+		orders[i] = optional.ObjectVal(map[string]optional.Value{
+			"id":      optional.Int64Val(int64(1000 + i)),
+			"item":    optional.StringVal("The Go Standard Library"),
+			"price":   optional.Float64Val(19.99),
+			"paid":    optional.BoolVal(i%2 == 0),
+			"created": optional.TimeVal(time.Date(2024, 5, 1, 8, 30, i, 0, time.UTC)),
+		})
+	}
+	return optional.ListVal(orders)
+}
+
+func BenchmarkMarshalMsgpack(b *testing.B) {
+	val := benchmarkValue()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := optional.MarshalMsgpack(val, val.Type()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	val := benchmarkValue()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := val.Converter().JSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalMsgpack(b *testing.B) {
+	val := benchmarkValue()
+	data, _ := optional.MarshalMsgpack(val, val.Type())
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := optional.UnmarshalMsgpack(data, val.Type()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalJSON(b *testing.B) {
+	val := benchmarkValue()
+	data, _ := val.Converter().JSON()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := optional.UnmarshalJSON(data, val.Type()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package optional
 
 import (
-	"encoding/base64"
+	"fmt"
 	"strings"
 )
 
@@ -37,11 +37,82 @@ func Base64StdEncode() Apply {
 		if err := val.value.GetError(); err != nil {
 			return err
 		}
-		if val.value.isString() {
-			val.value.v = base64.StdEncoding.EncodeToString(strToBytes(val.value.v.(string)))
+		s, err := val.value.Converter().Base64()
+		if err != nil {
+			return errorf(FmtMustString, val.field())
+		}
+		val.value = StringVal(s)
+		return nil
+	}
+}
+
+// Base64StdDecode replaces a standard base64 encoded string with the Bytes
+// value it encodes.
+func Base64StdDecode() Apply {
+	return func(val *processor) error {
+		if err := val.value.GetError(); err != nil {
+			return err
+		}
+		if !val.value.isString() {
+			return errorf(FmtMustString, val.field())
+		}
+		b, err := ParseBase64Val(val.value.v.(string))
+		if err != nil {
+			return errorf(FmtMustBase64, val.field())
+		}
+		val.value = b
+		return nil
+	}
+}
+
+// Each applies the given functions to every element of a list value.
+func Each(apply ...Apply) Apply {
+	return func(val *processor) error {
+		if err := val.value.GetError(); err != nil {
+			return err
+		}
+		elems, err := val.value.Converter().List()
+		if err != nil {
+			return errorf(FmtMustList, val.field())
+		}
+		if len(elems) == 0 {
 			return nil
 		}
-		return errorf(FmtMustString, val.name)
+		res := make([]Value, len(elems))
+		for i := range elems {
+			elem := processor{
+				name:    fmt.Sprintf("%s[%d]", val.name, i),
+				source:  val.source,
+				applies: apply,
+				value:   elems[i],
+			}.Value()
+			if err := elem.GetError(); err != nil {
+				return err
+			}
+			res[i] = elem
+		}
+		val.value = unifiedListVal(res)
+		return val.value.GetError()
+	}
+}
+
+// ExpandEnv expands ${VAR}, ${VAR:-default} and ${VAR:?message} references
+// in a string value, or in all strings nested in a list, map or object
+// value. Variables are looked up with resolve, or in the environment of the
+// process if resolve is nil. A reference to a variable that is not set is
+// an error.
+func ExpandEnv(resolve EnvResolver) Apply {
+	resolve = defaultEnvResolver(resolve)
+	return func(val *processor) error {
+		if err := val.value.GetError(); err != nil {
+			return err
+		}
+		res, err := expandEnvVal(val.value, val.source, val.name, resolve)
+		if err != nil {
+			return err
+		}
+		val.value = res
+		return nil
 	}
 }
 
@@ -2,6 +2,7 @@ package optional
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"html"
 	"math/big"
 	"net/url"
@@ -17,6 +18,11 @@ type stringProcessor interface {
 	TrimSuffix(s string) stringProcessor
 	RemoveSpace() stringProcessor
 	ToUpper() stringProcessor
+	ExpandEnv(resolve EnvResolver) stringProcessor
+	Base64StdEncode() stringProcessor
+	Base64StdDecode() stringProcessor
+	HexEncode() stringProcessor
+	HexDecode() stringProcessor
 }
 
 type numberProcessor interface {
@@ -34,172 +40,178 @@ func (o *linkProcessor) Value() Value {
 	return o.v
 }
 
+// failed reports whether an earlier step of the chain failed. A value that
+// is not a string, such as the bytes of a decoded base64 string, fails the
+// chain too.
+func (o *linkProcessor) failed() bool {
+	if o.err == nil && !o.v.isString() {
+		o.err = errorf(FmtMustString, "string processor")
+	}
+	return o.err != nil
+}
+
 func (o *linkProcessor) stringMap(fn func(a, v string) string, s string) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = fn(o.v.v.(string), s)
 	return o
 }
 func (o *linkProcessor) Trim(s string) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	return o.stringMap(strings.Trim, s)
 }
 func (o *linkProcessor) TrimSpace() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = strings.TrimSpace(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) TrimLeft(s string) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	return o.stringMap(strings.TrimLeft, s)
 }
 func (o *linkProcessor) TrimRight(s string) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	return o.stringMap(strings.TrimRight, s)
 }
 func (o *linkProcessor) TrimPrefix(s string) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	return o.stringMap(strings.TrimPrefix, s)
 }
 func (o *linkProcessor) TrimSuffix(s string) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	return o.stringMap(strings.TrimSuffix, s)
 }
 func (o *linkProcessor) RemoveSpace() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = strings.ReplaceAll(o.v.v.(string), " ", "")
 	return o
 }
 func (o *linkProcessor) ToUpper() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = strings.ToUpper(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) ToLower() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = strings.ToLower(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) ToPascalCase() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = ToPascalCase(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) ToCamelCase() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = ToCamelCase(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) ToSnakeCase() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = ToSnakeCase(o.v.v.(string))
 	return o
 }
 
-func (o *linkProcessor) Base64StdEncode() stringProcessor {
+func (o *linkProcessor) encode(fn func(b []byte) string) stringProcessor {
 	if o.err != nil {
 		return o
 	}
-	o.v.v = base64.StdEncoding.EncodeToString(strToBytes(o.v.v.(string)))
-	return o
-}
-func (o *linkProcessor) Base64StdDecode() stringProcessor {
-	if o.err != nil {
+	b, err := o.v.Converter().Bytes()
+	if err != nil {
+		o.err = errorf(FmtMustString, "string processor")
 		return o
 	}
-	o.v.v, o.err = base64.StdEncoding.DecodeString(o.v.v.(string))
+	o.v = StringVal(fn(b))
 	return o
 }
-func (o *linkProcessor) Base64RawStdEncode() stringProcessor {
-	if o.err != nil {
+
+// decode replaces the string with the bytes it encodes, so the result is a
+// Bytes value that only encoders can process further.
+func (o *linkProcessor) decode(fn func(s string) ([]byte, error)) stringProcessor {
+	if o.failed() {
 		return o
 	}
-	o.v.v = base64.RawStdEncoding.EncodeToString(strToBytes(o.v.v.(string)))
-	return o
-}
-func (o *linkProcessor) Base64RawStdDecode() stringProcessor {
-	if o.err != nil {
+	b, err := fn(o.v.v.(string))
+	if err != nil {
+		o.err = err
 		return o
 	}
-	b, err := base64.RawStdEncoding.DecodeString(o.v.v.(string))
-	o.err = err
-	o.v.v = bytesToStr(b)
+	o.v = BytesVal(b)
 	return o
 }
+
+func (o *linkProcessor) Base64StdEncode() stringProcessor {
+	return o.encode(base64.StdEncoding.EncodeToString)
+}
+func (o *linkProcessor) Base64StdDecode() stringProcessor {
+	return o.decode(base64.StdEncoding.DecodeString)
+}
+func (o *linkProcessor) Base64RawStdEncode() stringProcessor {
+	return o.encode(base64.RawStdEncoding.EncodeToString)
+}
+func (o *linkProcessor) Base64RawStdDecode() stringProcessor {
+	return o.decode(base64.RawStdEncoding.DecodeString)
+}
 func (o *linkProcessor) Base64URLEncode() stringProcessor {
-	if o.err != nil {
-		return o
-	}
-	o.v.v = base64.URLEncoding.EncodeToString(strToBytes(o.v.v.(string)))
-	return o
+	return o.encode(base64.URLEncoding.EncodeToString)
 }
 func (o *linkProcessor) Base64URLDecode() stringProcessor {
-	if o.err != nil {
-		return o
-	}
-	bytes, err := base64.URLEncoding.DecodeString(o.v.v.(string))
-	o.v.v = bytesToStr(bytes)
-	o.err = err
-	return o
+	return o.decode(base64.URLEncoding.DecodeString)
 }
 func (o *linkProcessor) Base64RawURLEncode() stringProcessor {
-	if o.err != nil {
-		return o
-	}
-	o.v.v = base64.RawURLEncoding.EncodeToString(strToBytes(o.v.v.(string)))
-	return o
+	return o.encode(base64.RawURLEncoding.EncodeToString)
 }
 func (o *linkProcessor) Base64RawURLDecode() stringProcessor {
-	if o.err != nil {
-		return o
-	}
-	bytes, err := base64.RawURLEncoding.DecodeString(o.v.v.(string))
-	o.v.v = bytesToStr(bytes)
-	o.err = err
-	return o
+	return o.decode(base64.RawURLEncoding.DecodeString)
+}
+func (o *linkProcessor) HexEncode() stringProcessor {
+	return o.encode(hex.EncodeToString)
+}
+func (o *linkProcessor) HexDecode() stringProcessor {
+	return o.decode(hex.DecodeString)
 }
 
 func (o *linkProcessor) HTMLUnescape() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = html.UnescapeString(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) HTMLEscape() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = html.EscapeString(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) URLPathUnescape() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	value, err := url.PathUnescape(o.v.v.(string))
@@ -208,22 +220,37 @@ func (o *linkProcessor) URLPathUnescape() stringProcessor {
 	return o
 }
 func (o *linkProcessor) URLPathEscape() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = url.PathEscape(o.v.v.(string))
 	return o
 }
 func (o *linkProcessor) URLQueryEscape() stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	o.v.v = url.QueryEscape(o.v.v.(string))
 	return o
 }
 
+// ExpandEnv expands the variable references in the string, see the
+// ExpandEnv processor.
+func (o *linkProcessor) ExpandEnv(resolve EnvResolver) stringProcessor {
+	if o.failed() {
+		return o
+	}
+	value, err := expandEnv(o.v.v.(string), defaultEnvResolver(resolve))
+	if err != nil {
+		o.err = err
+		return o
+	}
+	o.v.v = value
+	return o
+}
+
 func (o *linkProcessor) JoinStr(values ...string) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	var value strings.Builder
@@ -235,7 +262,7 @@ func (o *linkProcessor) JoinStr(values ...string) stringProcessor {
 	return o
 }
 func (o *linkProcessor) JoinBytes(values ...[]byte) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	var value strings.Builder
@@ -247,7 +274,7 @@ func (o *linkProcessor) JoinBytes(values ...[]byte) stringProcessor {
 	return o
 }
 func (o *linkProcessor) JoinByte(values ...byte) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	var value strings.Builder
@@ -259,7 +286,7 @@ func (o *linkProcessor) JoinByte(values ...byte) stringProcessor {
 	return o
 }
 func (o *linkProcessor) JoinRune(values ...rune) stringProcessor {
-	if o.err != nil {
+	if o.failed() {
 		return o
 	}
 	var value strings.Builder
@@ -6,10 +6,16 @@ import (
 
 type processor struct {
 	name    string
+	source  string
 	applies []Apply
 	value   Value
 }
 
+// field returns the field name as shown in error messages, see validator.
+func (o processor) field() string {
+	return fieldName(o.source, o.name)
+}
+
 func (o processor) GetError() error {
 	if err := o.value.GetError(); err != nil {
 		return err
@@ -41,7 +47,7 @@ func (o processor) Align(a align) error {
 }
 
 func (o processors) Validate(name string, match ...Match) validator {
-	return o.value.Validate(name, match...)
+	return o.Value().Validate(name, match...)
 }
 
 type processors struct {
@@ -68,28 +74,21 @@ func (o processors) Value() Value {
 		if err := value.GetError(); err != nil {
 			return value
 		}
-		//todo
-		o.value.SetMapValue(o.values[i].name, value)
+		o.value = o.value.SetMapValue(o.values[i].name, value)
 	}
 	return o.value
 }
 
 func (o processors) Aligns(aligns ...align) error {
-	if err := o.value.GetError(); err != nil {
-		return err
-	}
-	return o.value.Aligns(aligns...)
+	return o.Value().Aligns(aligns...)
 }
 
 func (o processors) Align(a align) error {
-	if err := o.value.GetError(); err != nil {
-		return err
-	}
-	return o.value.Aligns(a)
+	return o.Value().Aligns(a)
 }
 
 func (o processors) Validates(validates ...validator) validators {
-	return o.value.Validates(validates...)
+	return o.Value().Validates(validates...)
 }
 
 type Apply func(val *processor) error
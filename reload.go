@@ -0,0 +1,247 @@
+package optional
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reloader keeps a configuration loaded by a Config up to date. It polls the
+// modification time and size of the config files, hashing a file whose stamp
+// changed or is too recent to be trusted, and when the content of one of
+// them changes, loads the whole Config again. The new configuration is only
+// swapped in when loading, including validation, succeeds, so a bad config
+// file never replaces a good configuration.
+type Reloader struct {
+	config   *Config
+	newDst   func() interface{}
+	interval time.Duration
+
+	current atomic.Value // reloaded
+
+	checkMu sync.Mutex // serializes Check, so an older load never replaces a newer one
+
+	mu        sync.Mutex
+	stamps    map[string]fileStamp
+	onChange  []func(cfg interface{})
+	onError   []func(err error)
+	listeners []chan interface{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+type reloaded struct {
+	dst interface{}
+	val Value
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+	checked time.Time // when the file was hashed
+}
+
+// racyModTime is the modification time granularity assumed for the file
+// systems config files live on. A file modified this close to the time it
+// was hashed may be rewritten within the same tick, so it is hashed again.
+const racyModTime = 2 * time.Second
+
+// NewReloader loads config into the value returned by newDst, which must be
+// a pointer to a config struct, and returns a Reloader that reloads it every
+// interval once started. Every reload loads into a fresh value from newDst,
+// so a configuration handed out by Current is never modified.
+func NewReloader(config *Config, newDst func() interface{}, interval time.Duration) (*Reloader, error) {
+	if interval <= 0 {
+		return nil, errors.New("reload interval must be positive")
+	}
+	r := &Reloader{
+		config:   config,
+		newDst:   newDst,
+		interval: interval,
+		stamps:   map[string]fileStamp{},
+	}
+	if _, err := r.stampFiles(); err != nil {
+		return nil, err
+	}
+	dst := newDst()
+	val, err := config.Load(dst)
+	if err != nil {
+		return nil, err
+	}
+	r.current.Store(reloaded{dst: dst, val: val})
+	return r, nil
+}
+
+// Current returns the configuration most recently loaded successfully.
+func (r *Reloader) Current() interface{} {
+	return r.current.Load().(reloaded).dst
+}
+
+// Value returns the merged Value of the configuration returned by Current.
+func (r *Reloader) Value() Value {
+	return r.current.Load().(reloaded).val
+}
+
+// OnChange registers fn to be called with the new configuration after each
+// successful reload.
+func (r *Reloader) OnChange(fn func(cfg interface{})) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onChange = append(r.onChange, fn)
+}
+
+// OnError registers fn to be called when a changed config file can not be
+// read or fails loading. The current configuration stays in place.
+func (r *Reloader) OnError(fn func(err error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onError = append(r.onError, fn)
+}
+
+// Subscribe returns a channel that receives the new configuration after each
+// successful reload. A subscriber that falls behind only receives the latest
+// configuration.
+func (r *Reloader) Subscribe() <-chan interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch := make(chan interface{}, 1)
+	r.listeners = append(r.listeners, ch)
+	return ch
+}
+
+// Start polls the config files every interval in a new goroutine until Stop
+// is called.
+func (r *Reloader) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.run(r.stop, r.done)
+}
+
+// Stop stops polling and waits for a reload in progress to finish.
+func (r *Reloader) Stop() {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (r *Reloader) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_, _ = r.Check()
+		}
+	}
+}
+
+// Check polls the config files once and reloads the configuration if one of
+// them changed. It returns true if a new configuration was swapped in.
+// Checks run one at a time, the OnChange and OnError functions must not call
+// Check.
+func (r *Reloader) Check() (bool, error) {
+	r.checkMu.Lock()
+	defer r.checkMu.Unlock()
+	changed, err := r.stampFiles()
+	if err != nil {
+		r.notifyError(err)
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+	dst := r.newDst()
+	val, err := r.config.Load(dst)
+	if err != nil {
+		r.notifyError(err)
+		return false, err
+	}
+	r.current.Store(reloaded{dst: dst, val: val})
+	r.notifyChange(dst)
+	return true, nil
+}
+
+// stampFiles records the current state of the config files and returns true
+// if any of them changed since the last call.
+func (r *Reloader) stampFiles() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	now := time.Now()
+	for _, path := range r.config.Files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		prev, ok := r.stamps[path]
+		if ok && info.Size() == prev.size && info.ModTime().Equal(prev.modTime) &&
+			prev.modTime.Before(prev.checked.Add(-racyModTime)) {
+			continue
+		}
+		// the modification time is too coarse on some file systems and
+		// changes on a touch, so the content decides
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		stamp := fileStamp{modTime: info.ModTime(), size: int64(len(content)), sum: sha256.Sum256(content), checked: now}
+		if !ok || stamp.size != prev.size || stamp.sum != prev.sum {
+			changed = true
+		}
+		r.stamps[path] = stamp
+	}
+	return changed, nil
+}
+
+func (r *Reloader) notifyChange(cfg interface{}) {
+	r.mu.Lock()
+	fns := append([]func(cfg interface{}){}, r.onChange...)
+	listeners := append([]chan interface{}{}, r.listeners...)
+	r.mu.Unlock()
+	for _, fn := range fns {
+		fn(cfg)
+	}
+	for _, ch := range listeners {
+		select {
+		case ch <- cfg:
+		default:
+			// drop the stale configuration the subscriber has not read yet
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- cfg:
+			default:
+			}
+		}
+	}
+}
+
+func (r *Reloader) notifyError(err error) {
+	r.mu.Lock()
+	fns := append([]func(err error){}, r.onError...)
+	r.mu.Unlock()
+	for _, fn := range fns {
+		fn(err)
+	}
+}
@@ -0,0 +1,218 @@
+package optional
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+const sourceColumn = "column"
+
+// SQLValue wraps a Value so that it can be scanned from a database column
+// and written as a query argument with database/sql. Value can not do this
+// itself, its Value method already returns the Value.
+//
+// Scanned values become primitive values: integers become Int64, floats
+// Float64, booleans Bool, times Time and text String. Bytes are converted to
+// the type Val held before the scan, such as the type of the column RowsVal
+// scans into, and become Bytes if it held none. NULL becomes a null value of
+// the type Val held before the scan, or of DynamicPseudoType if it held none.
+type SQLValue struct {
+	Val Value
+}
+
+// Scan implements sql.Scanner.
+func (s *SQLValue) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		ty := s.Val.ty
+		if ty.typeImpl == nil {
+			ty = DynamicPseudoType
+		}
+		s.Val = NullVal(ty)
+	case int64:
+		s.Val = Int64Val(v)
+	case float64:
+		s.Val = Float64Val(v)
+	case bool:
+		s.Val = BoolVal(v)
+	case []byte:
+		return s.scanBytes(v)
+	case string:
+		s.Val = StringVal(v)
+	case time.Time:
+		s.Val = TimeVal(v)
+	default:
+		return fmt.Errorf("unsupported sql value %T", src)
+	}
+	return nil
+}
+
+// scanBytes scans bytes into the type Val held before the scan. Many drivers
+// return text and numbers as bytes, so they are parsed as text unless the
+// type is Bytes.
+func (s *SQLValue) scanBytes(b []byte) error {
+	ty := s.Val.ty
+	if ty.typeImpl == nil || ty.IsDynamicType() || ty.Equals(Bytes) {
+		// the driver owns b, it may be reused by the next scan
+		s.Val = BytesVal(append([]byte(nil), b...))
+		return nil
+	}
+	val, err := StringVal(string(b)).Converter().To(ty)
+	if err != nil {
+		return fmt.Errorf("converting sql bytes to %s: %w", ty.FriendlyName(), err)
+	}
+	s.Val = val
+	return nil
+}
+
+// Value implements driver.Valuer. Lists, maps and objects are written as
+// JSON text, a null value as NULL.
+func (s SQLValue) Value() (driver.Value, error) {
+	val := s.Val
+	if err := val.GetError(); err != nil {
+		return nil, err
+	}
+	if val.IsNull() {
+		return nil, nil
+	}
+	switch v := val.v.(type) {
+	case string, bool, int64, float64, time.Time:
+		return v, nil
+	case time.Duration:
+		return int64(v), nil
+	case []byte:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint:
+		return sqlUint(uint64(v))
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return sqlUint(v)
+	case float32:
+		return float64(v), nil
+	case *big.Float, *big.Int:
+		// decimals are written as text, which keeps every digit
+		return val.Converter().String()
+	}
+	if val.IsListValue() || val.IsMapValue() || val.IsObjectValue() {
+		b, err := val.Converter().JSON()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	return nil, fmt.Errorf("unsupported sql value of type %s", val.ty.FriendlyName())
+}
+
+func sqlUint(v uint64) (driver.Value, error) {
+	if v > math.MaxInt64 {
+		return nil, fmt.Errorf("uint64 value %d overflows int64", v)
+	}
+	return int64(v), nil
+}
+
+// RowsVal reads all rows and returns them as a list of map Values keyed by
+// column name, the rows are closed when done. A NULL column is a null value
+// of the column's type as far as the driver reports it. The keys report
+// their source as column, so validation errors read column `name`.
+func RowsVal(rows *sql.Rows) Value {
+	defer rows.Close()
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return Value{err: err}
+	}
+	types := make([]Type, len(columns))
+	for i := range columns {
+		types[i] = sqlColumnType(columns[i].ScanType())
+	}
+	var list []Value
+	dest := make([]SQLValue, len(columns))
+	args := make([]interface{}, len(columns))
+	for rows.Next() {
+		for i := range dest {
+			dest[i] = SQLValue{Val: NullVal(types[i])}
+			args[i] = &dest[i]
+		}
+		if err := rows.Scan(args...); err != nil {
+			return Value{err: err}
+		}
+		vals := make(map[string]Value, len(columns))
+		for i := range columns {
+			vals[columns[i].Name()] = dest[i].Val
+		}
+		list = append(list, MapStringVal(vals).withSource(sourceColumn))
+	}
+	if err := rows.Err(); err != nil {
+		return Value{err: err}
+	}
+	if len(list) == 0 {
+		return ListValEmpty(StringMap())
+	}
+	return ListVal(list)
+}
+
+var (
+	sqlNullInt64   = reflect.TypeOf(sql.NullInt64{})
+	sqlNullInt32   = reflect.TypeOf(sql.NullInt32{})
+	sqlNullFloat64 = reflect.TypeOf(sql.NullFloat64{})
+	sqlNullBool    = reflect.TypeOf(sql.NullBool{})
+	sqlNullTime    = reflect.TypeOf(sql.NullTime{})
+	sqlRawBytes    = reflect.TypeOf(sql.RawBytes{})
+)
+
+// sqlColumnType returns the type of the values scanned from a column whose
+// driver reports the given scan type.
+func sqlColumnType(t reflect.Type) Type {
+	if t == nil {
+		return DynamicPseudoType
+	}
+	switch t {
+	case sqlNullInt64, sqlNullInt32:
+		return Int64
+	case sqlNullFloat64:
+		return Float64
+	case sqlNullBool:
+		return Bool
+	case sqlNullTime, timeType:
+		return Time
+	case sqlRawBytes:
+		// drivers report raw bytes for text columns as well
+		return String
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Int64
+	case reflect.Float32, reflect.Float64:
+		return Float64
+	case reflect.Bool:
+		return Bool
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Bytes
+		}
+		return String
+	default:
+		return String
+	}
+}
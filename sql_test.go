@@ -0,0 +1,170 @@
+package optional_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorpher/optional/v2"
+)
+
+// fakeDriver serves a fixed table to every query.
+type fakeDriver struct{}
+
+type fakeConn struct{}
+
+type fakeStmt struct{}
+
+type fakeRows struct {
+	i int
+}
+
+var fakeTable = [][]driver.Value{
+	{int64(1), "gorpher", []byte("gorpher@gmail.com"), 24.5, true},
+	{int64(2), "bob", nil, nil, false},
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+func (r *fakeRows) Columns() []string { return []string{"id", "name", "email", "score", "active"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i == len(fakeTable) {
+		return io.EOF
+	}
+	copy(dest, fakeTable[r.i])
+	r.i++
+	return nil
+}
+
+// ColumnTypeScanType reports the scan types, so that NULL columns keep
+// their type.
+func (r *fakeRows) ColumnTypeScanType(index int) reflect.Type {
+	return []reflect.Type{
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(""),
+		reflect.TypeOf(sql.NullString{}),
+		reflect.TypeOf(sql.NullFloat64{}),
+		reflect.TypeOf(false),
+	}[index]
+}
+
+func init() {
+	sql.Register("optionaltest", fakeDriver{})
+}
+
+func TestRowsVal(t *testing.T) {
+	db, err := sql.Open("optionaltest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT id, name, email, score, active FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := optional.RowsVal(rows)
+	if err := list.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	first := list.GetListValue(0)
+	if err := first.Validates(
+		optional.Validate("name", optional.MustIsLetter()),
+		optional.Validate("email", optional.MustHasSuffix("@gmail.com")),
+	).GetError(); err != nil {
+		t.Error(err)
+	}
+	var user struct {
+		ID     int64
+		Name   string
+		Email  string
+		Score  float64
+		Active bool
+	}
+	if err := first.UnMarshal(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 || user.Name != "gorpher" || user.Email != "gorpher@gmail.com" || user.Score != 24.5 || !user.Active {
+		t.Errorf("wrong result\ngot:  %+v", user)
+	}
+
+	second := list.GetListValue(1)
+	if score := second.GetMapValue("score"); !score.IsNull() || score.Type() != optional.Float64 {
+		t.Errorf("wrong result\ngot:  %#v", score.Type())
+	}
+	err = second.Validates(optional.Validate("email", optional.MustHasSuffix("@gmail.com"))).GetError()
+	if err == nil {
+		t.Error("expected an error for a NULL column")
+	}
+	err = second.Validates(optional.Validate("name", optional.MustHasSuffix("@gmail.com"))).GetError()
+	if want := "column `name` filed value must has @gmail.com suffix"; err == nil || err.Error() != want {
+		t.Errorf("wrong result\ngot:  %v\nwant: %s", err, want)
+	}
+}
+
+func TestSQLValue(t *testing.T) {
+	tests := []struct {
+		val  optional.Value
+		want driver.Value
+	}{
+		{optional.StringVal("a"), "a"},
+		{optional.IntVal(3), int64(3)},
+		{optional.Uint8Val(3), int64(3)},
+		{optional.Float32Val(1.5), float64(1.5)},
+		{optional.BoolVal(true), true},
+		{optional.NullVal(optional.String), nil},
+		{optional.ListVal([]optional.Value{optional.IntVal(1), optional.IntVal(2)}), "[1,2]"},
+	}
+	for _, test := range tests {
+		got, err := optional.SQLValue{Val: test.val}.Value()
+		if err != nil || got != test.want {
+			t.Errorf("wrong result\ngot:  %#v %v\nwant: %#v", got, err, test.want)
+		}
+	}
+	if _, err := (optional.SQLValue{Val: optional.Uint64Val(1 << 63)}).Value(); err == nil {
+		t.Error("expected an overflow error")
+	}
+
+	s := optional.SQLValue{Val: optional.NullVal(optional.Int64)}
+	if err := s.Scan(nil); err != nil || !s.Val.IsNull() || s.Val.Type() != optional.Int64 {
+		t.Errorf("wrong result\ngot:  %v %v", s.Val, err)
+	}
+	if err := s.Scan([]byte("42")); err != nil || !s.Val.Equals(optional.Int64Val(42)) {
+		t.Errorf("wrong result\ngot:  %v %v", s.Val, err)
+	}
+	if err := s.Scan([]byte("text")); err == nil {
+		t.Errorf("expected error for text scanned into Int64\ngot:  %v", s.Val)
+	}
+	text := optional.SQLValue{Val: optional.NullVal(optional.String)}
+	if err := text.Scan([]byte("text")); err != nil || !text.Val.Equals(optional.StringVal("text")) {
+		t.Errorf("wrong result\ngot:  %v %v", text.Val, err)
+	}
+	raw := []byte{0xff, 0x00}
+	var blob optional.SQLValue
+	if err := blob.Scan(raw); err != nil || !blob.Val.Type().Equals(optional.Bytes) {
+		t.Errorf("wrong result\ngot:  %#v %v", blob.Val, err)
+	}
+	raw[0] = 0
+	if !blob.Val.Equals(optional.BytesVal([]byte{0xff, 0x00})) {
+		t.Errorf("scanned bytes must be copied\ngot:  %v", blob.Val)
+	}
+	now := time.Now()
+	if err := s.Scan(now); err != nil || !s.Val.Equals(optional.TimeVal(now)) {
+		t.Errorf("wrong result\ngot:  %v %v", s.Val, err)
+	}
+}
@@ -0,0 +1,56 @@
+package optional
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Pseudo layouts for times given as a number, to be used along with the
+// layouts of the time package.
+const (
+	TimeLayoutUnix      = "unix"      // seconds since January 1, 1970 UTC
+	TimeLayoutUnixMilli = "unixmilli" // milliseconds since January 1, 1970 UTC
+)
+
+// DefaultTimeLayouts are the layouts tried, in order, when a string is
+// converted to a time without giving layouts, such as by UnMarshal.
+var DefaultTimeLayouts = []string{time.RFC3339, "2006-01-02", TimeLayoutUnix}
+
+// parseTime parses s with the first of the layouts that accepts it.
+func parseTime(s string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		switch layout {
+		case TimeLayoutUnix, TimeLayoutUnixMilli:
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err == nil {
+				return unixTime(n, layout), nil
+			}
+		default:
+			t, err := time.Parse(layout, s)
+			if err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("time %q must match one of the layouts %q", s, layouts)
+}
+
+// unixTime returns the time of a number read with a unix pseudo layout.
+func unixTime(n int64, layout string) time.Time {
+	if layout == TimeLayoutUnixMilli {
+		return time.Unix(n/1e3, n%1e3*1e6).UTC()
+	}
+	return time.Unix(n, 0).UTC()
+}
+
+// unixLayout returns the first unix pseudo layout of layouts, numbers are
+// read with it.
+func unixLayout(layouts []string) (string, bool) {
+	for _, layout := range layouts {
+		if layout == TimeLayoutUnix || layout == TimeLayoutUnixMilli {
+			return layout, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,142 @@
+package optional
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type typeDecimal struct {
+	typeImplSigil
+	Scale int
+}
+
+// DecimalType returns the type of decimal numbers with the given number of
+// digits after the decimal point, such as 2 for amounts of money. A decimal
+// value is exact, it is never rounded through a float. It panics if scale
+// is negative.
+func DecimalType(scale int) Type {
+	if scale < 0 {
+		panic("decimal scale must not be negative")
+	}
+	return Type{
+		typeDecimal{Scale: scale},
+	}
+}
+
+func (t typeDecimal) Equals(other Type) bool {
+	if otherD, ok := other.typeImpl.(typeDecimal); ok {
+		return otherD.Scale == t.Scale
+	}
+	return false
+}
+
+func (t typeDecimal) FriendlyName() string {
+	return fmt.Sprintf("decimal(%d)", t.Scale)
+}
+
+func (t typeDecimal) GoString() string {
+	return fmt.Sprintf("optional.DecimalType(%d)", t.Scale)
+}
+
+// IsDecimalType returns true if the given type is a decimal type.
+func (t Type) IsDecimalType() bool {
+	_, ok := t.typeImpl.(typeDecimal)
+	return ok
+}
+
+// DecimalScale returns the scale of a decimal type. It panics if the type is
+// not a decimal type.
+func (t Type) DecimalScale() int {
+	if dt, ok := t.typeImpl.(typeDecimal); ok {
+		return dt.Scale
+	}
+	panic("DecimalScale on non-decimal Type")
+}
+
+// DecimalVal returns a Value of type DecimalType(scale) holding r rounded to
+// scale digits after the decimal point with the given rounding mode, such as
+// big.ToNearestAway to round half up or big.ToNearestEven for banker's
+// rounding.
+func DecimalVal(r *big.Rat, scale int, mode big.RoundingMode) Value {
+	if scale < 0 {
+		return Value{err: fmt.Errorf("decimal scale must not be negative")}
+	}
+	return Value{
+		ty: DecimalType(scale),
+		v:  roundRat(r, scale, mode),
+	}
+}
+
+// ParseDecimalVal parses a decimal number, such as "12.50" or "1.5e3", and
+// returns it rounded to scale digits after the decimal point, see DecimalVal.
+func ParseDecimalVal(s string, scale int, mode big.RoundingMode) (Value, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return NilVal, fmt.Errorf("a decimal number is required")
+	}
+	val := DecimalVal(r, scale, mode)
+	return val, val.GetError()
+}
+
+// roundRat returns r * 10^scale rounded to an integer with the given mode.
+func roundRat(r *big.Rat, scale int, mode big.RoundingMode) *big.Int {
+	n := new(big.Int).Mul(r.Num(), pow10(scale))
+	d := r.Denom()
+	q, m := new(big.Int).QuoRem(n, d, new(big.Int))
+	if m.Sign() == 0 {
+		return q
+	}
+	neg := n.Sign() < 0
+	// half compares the remainder with half of the denominator
+	half := new(big.Int).Abs(m)
+	half.Lsh(half, 1)
+	c := half.Cmp(d)
+	away := false
+	switch mode {
+	case big.ToZero:
+	case big.AwayFromZero:
+		away = true
+	case big.ToNegativeInf:
+		away = neg
+	case big.ToPositiveInf:
+		away = !neg
+	case big.ToNearestAway:
+		away = c >= 0
+	default: // big.ToNearestEven
+		away = c > 0 || (c == 0 && q.Bit(0) == 1)
+	}
+	if away {
+		if neg {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// decimalRat returns the exact value of a decimal held as unscaled integer.
+func decimalRat(unscaled *big.Int, scale int) *big.Rat {
+	return new(big.Rat).SetFrac(unscaled, pow10(scale))
+}
+
+// decimalString formats a decimal held as unscaled integer with exactly
+// scale digits after the decimal point.
+func decimalString(unscaled *big.Int, scale int) string {
+	s := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(s) <= scale {
+			s = strings.Repeat("0", scale-len(s)+1) + s
+		}
+		s = s[:len(s)-scale] + "." + s[len(s)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
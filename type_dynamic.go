@@ -0,0 +1,32 @@
+package optional
+
+type pseudoTypeDynamic struct {
+	typeImplSigil
+}
+
+// DynamicPseudoType stands for a type that is not known ahead of time, such
+// as the type of a JSON null or of the elements of an empty JSON array. It
+// is not a real type: only null values are of DynamicPseudoType, and a list
+// of DynamicPseudoType may hold elements of any type, see Unify.
+var DynamicPseudoType = Type{
+	pseudoTypeDynamic{},
+}
+
+func (t pseudoTypeDynamic) Equals(other Type) bool {
+	_, ok := other.typeImpl.(pseudoTypeDynamic)
+	return ok
+}
+
+func (t pseudoTypeDynamic) FriendlyName() string {
+	return "dynamic"
+}
+
+func (t pseudoTypeDynamic) GoString() string {
+	return "optional.DynamicPseudoType"
+}
+
+// IsDynamicType returns true if the given type is DynamicPseudoType.
+func (t Type) IsDynamicType() bool {
+	_, ok := t.typeImpl.(pseudoTypeDynamic)
+	return ok
+}
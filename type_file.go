@@ -0,0 +1,44 @@
+package optional
+
+import "mime/multipart"
+
+type typeFile struct {
+	typeImplSigil
+}
+
+// File is the type of uploaded files. A File value holds the
+// *multipart.FileHeader of the upload, which carries its file name, size and
+// MIME header and opens its content.
+var File = Type{
+	typeFile{},
+}
+
+func (t typeFile) Equals(other Type) bool {
+	_, ok := other.typeImpl.(typeFile)
+	return ok
+}
+
+func (t typeFile) FriendlyName() string {
+	return "file"
+}
+
+func (t typeFile) GoString() string {
+	return "optional.File"
+}
+
+// IsFileType returns true if the given type is the File type.
+func (t Type) IsFileType() bool {
+	_, ok := t.typeImpl.(typeFile)
+	return ok
+}
+
+// FileVal returns a Value of type File for the given uploaded file.
+func FileVal(fh *multipart.FileHeader) Value {
+	if fh == nil {
+		return NullVal(File)
+	}
+	return Value{
+		ty: File,
+		v:  fh,
+	}
+}
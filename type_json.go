@@ -0,0 +1,187 @@
+package optional
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MarshalJSON encodes the type in a stable JSON form, which UnmarshalJSON
+// reads back:
+//
+//	"string", "int64", "time", "bignumber", "file", "dynamic", ...
+//	["decimal", 2]
+//	["list", "int64"]
+//	["map", "string"]                         a map of strings
+//	["map", {"name": "string"}]               a map with typed keys
+//	["object", {"id": "int64", "name": "string"}]
+//	["object", {"id": "int64", "name": "string"}, ["name"]]
+//
+// The third element of an object lists its optional attributes. The
+// attributes of maps and objects are always sorted by name.
+func (t Type) MarshalJSON() ([]byte, error) {
+	switch impl := t.typeImpl.(type) {
+	case primitiveType:
+		return json.Marshal(string(impl.Kind))
+	case pseudoTypeDynamic:
+		return json.Marshal("dynamic")
+	case typeFile:
+		return json.Marshal("file")
+	case typeDecimal:
+		return json.Marshal([]interface{}{"decimal", impl.Scale})
+	case typeList:
+		return json.Marshal([]interface{}{"list", impl.ElementTypeT})
+	case typeStringMap:
+		if len(impl.AttrType) == 0 && impl.ElemType.typeImpl != nil {
+			return json.Marshal([]interface{}{"map", impl.ElemType})
+		}
+		attrs, err := marshalAttrTypes(impl.AttrType)
+		if err != nil {
+			return nil, err
+		}
+		if impl.ElemType.typeImpl != nil {
+			return json.Marshal([]interface{}{"map", attrs, impl.ElemType})
+		}
+		return json.Marshal([]interface{}{"map", attrs})
+	case typeObject:
+		attrs, err := marshalAttrTypes(impl.AttrTypes)
+		if err != nil {
+			return nil, err
+		}
+		if len(impl.AttrOptional) == 0 {
+			return json.Marshal([]interface{}{"object", attrs})
+		}
+		optional := make([]string, 0, len(impl.AttrOptional))
+		for name := range impl.AttrOptional {
+			optional = append(optional, name)
+		}
+		sort.Strings(optional)
+		return json.Marshal([]interface{}{"object", attrs, optional})
+	default:
+		return nil, errors.New("can not marshal an invalid type")
+	}
+}
+
+// marshalAttrTypes encodes attribute types as a JSON object. encoding/json
+// sorts map keys, which keeps the encoding stable.
+func marshalAttrTypes(types map[string]Type) (json.RawMessage, error) {
+	if types == nil {
+		types = map[string]Type{}
+	}
+	return json.Marshal(types)
+}
+
+// UnmarshalJSON decodes a type encoded by MarshalJSON.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		ty, ok := namedType(name)
+		if !ok {
+			return fmt.Errorf("unknown type %q", name)
+		}
+		*t = ty
+		return nil
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil || len(parts) < 2 {
+		return fmt.Errorf("invalid type %s", data)
+	}
+	var kind string
+	if err := json.Unmarshal(parts[0], &kind); err != nil {
+		return fmt.Errorf("invalid type %s", data)
+	}
+	switch {
+	case kind == "decimal" && len(parts) == 2:
+		var scale int
+		if err := json.Unmarshal(parts[1], &scale); err != nil || scale < 0 {
+			return fmt.Errorf("invalid decimal scale %s", parts[1])
+		}
+		*t = DecimalType(scale)
+	case kind == "list" && len(parts) == 2:
+		var elem Type
+		if err := elem.UnmarshalJSON(parts[1]); err != nil {
+			return err
+		}
+		*t = ListType(elem)
+	case kind == "map" && len(parts) <= 3:
+		return t.unmarshalMap(parts[1:])
+	case kind == "object" && len(parts) <= 3:
+		var attrs map[string]Type
+		if err := json.Unmarshal(parts[1], &attrs); err != nil {
+			return err
+		}
+		var optional []string
+		if len(parts) == 3 {
+			if err := json.Unmarshal(parts[2], &optional); err != nil {
+				return err
+			}
+			for _, name := range optional {
+				if _, ok := attrs[name]; !ok {
+					return fmt.Errorf("optional attribute %q is not an attribute", name)
+				}
+			}
+		}
+		*t = ObjectTypeWithOptionalAttrs(attrs, optional)
+	default:
+		return fmt.Errorf("invalid type %s", data)
+	}
+	return nil
+}
+
+func (t *Type) unmarshalMap(parts []json.RawMessage) error {
+	first := bytes.TrimSpace(parts[0])
+	if len(first) == 0 || first[0] != '{' {
+		// the element type form
+		if len(parts) != 1 {
+			return fmt.Errorf("invalid map type")
+		}
+		var elem Type
+		if err := elem.UnmarshalJSON(first); err != nil {
+			return err
+		}
+		*t = StringMapOf(elem)
+		return nil
+	}
+	var attrs map[string]Type
+	if err := json.Unmarshal(first, &attrs); err != nil {
+		return err
+	}
+	impl := typeStringMap{AttrType: attrs}
+	if len(attrs) == 0 {
+		impl.AttrType = nil
+	}
+	if len(parts) == 2 {
+		if err := impl.ElemType.UnmarshalJSON(parts[1]); err != nil {
+			return err
+		}
+	}
+	*t = Type{impl}
+	return nil
+}
+
+// namedType returns the type of the given name, as used by MarshalJSON and
+// ParseType.
+func namedType(name string) (Type, bool) {
+	switch name {
+	case "dynamic":
+		return DynamicPseudoType, true
+	case "file":
+		return File, true
+	}
+	for _, ty := range []Type{
+		Bool, String, Int, Int8, Int16, Int32, Int64,
+		Uint, Uint8, Uint16, Uint32, Uint64, Float32, Float64,
+		Time, Duration, Bytes, BigNumber,
+	} {
+		if string(ty.typeImpl.(primitiveType).Kind) == name {
+			return ty, true
+		}
+	}
+	return Type{}, false
+}
@@ -0,0 +1,48 @@
+package optional
+
+import "fmt"
+
+type typeList struct {
+	typeImplSigil
+	ElementTypeT Type
+}
+
+// ListType creates a list type whose elements are all of the given type.
+func ListType(elem Type) Type {
+	return Type{
+		typeList{
+			ElementTypeT: elem,
+		},
+	}
+}
+
+func (t typeList) Equals(other Type) bool {
+	if ot, ok := other.typeImpl.(typeList); ok {
+		return t.ElementTypeT.Equals(ot.ElementTypeT)
+	}
+	return false
+}
+
+func (t typeList) FriendlyName() string {
+	return "list of " + t.ElementTypeT.FriendlyName()
+}
+
+func (t typeList) GoString() string {
+	return fmt.Sprintf("optional.ListType(%#v)", t.ElementTypeT)
+}
+
+// IsListType returns true if the given type is a list type, regardless of its
+// element type.
+func (t Type) IsListType() bool {
+	_, ok := t.typeImpl.(typeList)
+	return ok
+}
+
+// ElementType returns the element type of the receiver if it is a list type.
+// It panics if the receiver is not a list type.
+func (t Type) ElementType() Type {
+	if lt, ok := t.typeImpl.(typeList); ok {
+		return lt.ElementTypeT
+	}
+	panic("ElementType on non-list Type")
+}
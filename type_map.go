@@ -9,6 +9,7 @@ import (
 type typeStringMap struct {
 	typeImplSigil
 	AttrType map[string]Type
+	ElemType Type // type of the keys missing from AttrType, if known
 }
 
 func StringMap() Type {
@@ -25,11 +26,30 @@ func StringMapType(ty map[string]Type) Type {
 	}
 }
 
+// StringMapOf returns the type of maps whose values are all of the given
+// type, such as StringMapOf(String) for a map of strings.
+func StringMapOf(elem Type) Type {
+	return Type{
+		typeStringMap{
+			ElemType: elem,
+		},
+	}
+}
+
 func (t typeStringMap) Equals(other Type) bool {
-	_, isMap := other.typeImpl.(typeStringMap)
-	return isMap
+	om, isMap := other.typeImpl.(typeStringMap)
+	if !isMap {
+		return false
+	}
+	if t.ElemType.typeImpl == nil || om.ElemType.typeImpl == nil {
+		return t.ElemType.typeImpl == om.ElemType.typeImpl
+	}
+	return t.ElemType.Equals(om.ElemType)
 }
 func (t typeStringMap) FriendlyName() string {
+	if len(t.AttrType) == 0 && t.ElemType.typeImpl != nil {
+		return "map of " + t.ElemType.FriendlyName()
+	}
 	b := bytes.NewBufferString("map of ")
 	b.WriteString("[")
 	for k, v := range t.AttrType {
@@ -41,6 +61,9 @@ func (t typeStringMap) FriendlyName() string {
 	return b.String()
 }
 func (t typeStringMap) GoString() string {
+	if len(t.AttrType) == 0 && t.ElemType.typeImpl != nil {
+		return fmt.Sprintf("optional.StringMapOf(%#v)", t.ElemType)
+	}
 	return fmt.Sprintf("optional.Map(%#value)", t.AttrType)
 }
 
@@ -50,7 +73,10 @@ func (t typeStringMap) UpdateAttrType(ty map[string]Type) {
 	}
 }
 func (t typeStringMap) GetStringMapType(key string) Type {
-	return t.AttrType[key]
+	if ty, ok := t.AttrType[key]; ok {
+		return ty
+	}
+	return t.ElemType
 }
 
 func (t Type) IsMapType() bool {
@@ -76,5 +102,8 @@ func (t Type) Len() int {
 	if lt, ok := t.typeImpl.(typeStringMap); ok {
 		return len(lt.AttrType)
 	}
+	if ot, ok := t.typeImpl.(typeObject); ok {
+		return len(ot.AttrTypes)
+	}
 	return 0
 }
@@ -0,0 +1,149 @@
+package optional
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+type typeObject struct {
+	typeImplSigil
+	AttrTypes    map[string]Type
+	AttrOptional map[string]struct{}
+}
+
+// ObjectType creates an object type whose attributes all are required and
+// have the given types.
+func ObjectType(attrTypes map[string]Type) Type {
+	return ObjectTypeWithOptionalAttrs(attrTypes, nil)
+}
+
+// ObjectTypeWithOptionalAttrs creates an object type where the named
+// attributes may be absent. Each name in optional must also appear in
+// attrTypes.
+func ObjectTypeWithOptionalAttrs(attrTypes map[string]Type, optional []string) Type {
+	attrs := make(map[string]Type, len(attrTypes))
+	for k, ty := range attrTypes {
+		attrs[k] = ty
+	}
+	opts := make(map[string]struct{}, len(optional))
+	for _, k := range optional {
+		if _, ok := attrs[k]; !ok {
+			panic("optional attribute " + k + " not in object type")
+		}
+		opts[k] = struct{}{}
+	}
+	return Type{
+		typeObject{
+			AttrTypes:    attrs,
+			AttrOptional: opts,
+		},
+	}
+}
+
+func (t typeObject) Equals(other Type) bool {
+	ot, ok := other.typeImpl.(typeObject)
+	if !ok {
+		return false
+	}
+	if len(t.AttrTypes) != len(ot.AttrTypes) || len(t.AttrOptional) != len(ot.AttrOptional) {
+		return false
+	}
+	for k, ty := range t.AttrTypes {
+		oty, ok := ot.AttrTypes[k]
+		if !ok || !ty.Equals(oty) {
+			return false
+		}
+	}
+	for k := range t.AttrOptional {
+		if _, ok := ot.AttrOptional[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (t typeObject) FriendlyName() string {
+	b := bytes.NewBufferString("object of ")
+	b.WriteString("[")
+	for _, k := range t.attributeNames() {
+		b.WriteString("(")
+		b.WriteString(k + "=")
+		if _, ok := t.AttrOptional[k]; ok {
+			b.WriteString("optional ")
+		}
+		b.WriteString(t.AttrTypes[k].FriendlyName())
+		b.WriteString(")")
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+func (t typeObject) GoString() string {
+	if len(t.AttrOptional) == 0 {
+		return fmt.Sprintf("optional.Object(%#v)", t.AttrTypes)
+	}
+	opts := make([]string, 0, len(t.AttrOptional))
+	for k := range t.AttrOptional {
+		opts = append(opts, k)
+	}
+	sort.Strings(opts)
+	return fmt.Sprintf("optional.ObjectWithOptionalAttrs(%#v, %#v)", t.AttrTypes, opts)
+}
+
+func (t typeObject) attributeNames() []string {
+	names := make([]string, 0, len(t.AttrTypes))
+	for k := range t.AttrTypes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsObjectType returns true if the given type is an object type, regardless
+// of its attributes.
+func (t Type) IsObjectType() bool {
+	_, ok := t.typeImpl.(typeObject)
+	return ok
+}
+
+// HasAttribute returns true if the receiver is an object type with an
+// attribute of the given name.
+func (t Type) HasAttribute(name string) bool {
+	if ot, ok := t.typeImpl.(typeObject); ok {
+		_, has := ot.AttrTypes[name]
+		return has
+	}
+	return false
+}
+
+// AttributeType returns the type of the named attribute of an object type.
+// It panics if the receiver is not an object type or has no such attribute.
+func (t Type) AttributeType(name string) Type {
+	if ot, ok := t.typeImpl.(typeObject); ok {
+		if ty, has := ot.AttrTypes[name]; has {
+			return ty
+		}
+		panic("no such attribute " + name)
+	}
+	panic("AttributeType on non-object Type")
+}
+
+// AttributeTypes returns the attribute types of an object type. The returned
+// map must not be modified. It panics if the receiver is not an object type.
+func (t Type) AttributeTypes() map[string]Type {
+	if ot, ok := t.typeImpl.(typeObject); ok {
+		return ot.AttrTypes
+	}
+	panic("AttributeTypes on non-object Type")
+}
+
+// AttributeOptional returns true if the receiver is an object type that
+// marks the named attribute as optional.
+func (t Type) AttributeOptional(name string) bool {
+	if ot, ok := t.typeImpl.(typeObject); ok {
+		_, opt := ot.AttrOptional[name]
+		return opt
+	}
+	return false
+}
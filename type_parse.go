@@ -0,0 +1,182 @@
+package optional
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// ParseType parses a type written in the compact syntax used by config files
+// and command line flags:
+//
+//	string, int64, bool, time, duration, bytes, bignumber, file, dynamic, ...
+//	decimal(2)
+//	list(int64)
+//	map                       a map of any values
+//	map(string)               a map of strings
+//	map({name=string})        a map with typed keys
+//	object({id=int64, name=optional(string)})
+//
+// Whitespace between tokens is ignored.
+func ParseType(s string) (Type, error) {
+	p := &typeParser{src: s}
+	ty, err := p.parseType()
+	if err != nil {
+		return Type{}, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return Type{}, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return ty, nil
+}
+
+type typeParser struct {
+	src string
+	pos int
+}
+
+func (p *typeParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid type %q at offset %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *typeParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek reports whether the next token is the given character.
+func (p *typeParser) peek(c byte) bool {
+	p.skipSpace()
+	return p.pos < len(p.src) && p.src[p.pos] == c
+}
+
+func (p *typeParser) expect(c byte) error {
+	if !p.peek(c) {
+		return p.errorf("%q is required", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *typeParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c != '_' && c != '-' && c != '.' && !unicode.IsLetter(rune(c)) && !unicode.IsDigit(rune(c)) {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *typeParser) parseType() (Type, error) {
+	name := p.ident()
+	switch name {
+	case "":
+		return Type{}, p.errorf("a type name is required")
+	case "list":
+		if err := p.expect('('); err != nil {
+			return Type{}, err
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return Type{}, err
+		}
+		return ListType(elem), p.expect(')')
+	case "map":
+		if !p.peek('(') {
+			return StringMap(), nil
+		}
+		p.pos++
+		if p.peek('{') {
+			attrs, optional, err := p.parseAttrs()
+			if err != nil {
+				return Type{}, err
+			}
+			if len(optional) > 0 {
+				return Type{}, p.errorf("map attributes can not be optional")
+			}
+			return StringMapType(attrs), p.expect(')')
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return Type{}, err
+		}
+		return StringMapOf(elem), p.expect(')')
+	case "object":
+		if err := p.expect('('); err != nil {
+			return Type{}, err
+		}
+		attrs, optional, err := p.parseAttrs()
+		if err != nil {
+			return Type{}, err
+		}
+		return ObjectTypeWithOptionalAttrs(attrs, optional), p.expect(')')
+	case "decimal":
+		if err := p.expect('('); err != nil {
+			return Type{}, err
+		}
+		scale, err := strconv.Atoi(p.ident())
+		if err != nil || scale < 0 {
+			return Type{}, p.errorf("decimal scale must be a non-negative integer")
+		}
+		return DecimalType(scale), p.expect(')')
+	}
+	ty, ok := namedType(name)
+	if !ok {
+		return Type{}, p.errorf("unknown type %q", name)
+	}
+	return ty, nil
+}
+
+// parseAttrs parses {name=type, ...}. An attribute type wrapped in
+// optional(...) is returned in optional.
+func (p *typeParser) parseAttrs() (map[string]Type, []string, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, nil, err
+	}
+	attrs := map[string]Type{}
+	var optional []string
+	for !p.peek('}') {
+		if len(attrs) > 0 {
+			if err := p.expect(','); err != nil {
+				return nil, nil, err
+			}
+		}
+		name := p.ident()
+		if name == "" {
+			return nil, nil, p.errorf("an attribute name is required")
+		}
+		if _, ok := attrs[name]; ok {
+			return nil, nil, p.errorf("duplicate attribute %q", name)
+		}
+		if err := p.expect('='); err != nil {
+			return nil, nil, err
+		}
+		isOptional := false
+		start := p.pos
+		if p.ident() == "optional" && p.peek('(') {
+			p.pos++
+			isOptional = true
+		} else {
+			p.pos = start
+		}
+		ty, err := p.parseType()
+		if err != nil {
+			return nil, nil, err
+		}
+		if isOptional {
+			if err := p.expect(')'); err != nil {
+				return nil, nil, err
+			}
+			optional = append(optional, name)
+		}
+		attrs[name] = ty
+	}
+	p.pos++
+	return attrs, optional, nil
+}
@@ -10,20 +10,24 @@ type primitiveType struct {
 type primitiveTypeKind string
 
 const (
-	primitiveTypeBool    primitiveTypeKind = "bool"
-	primitiveTypeString  primitiveTypeKind = "string"
-	primitiveTypeInt     primitiveTypeKind = "int"
-	primitiveTypeUint    primitiveTypeKind = "uint"
-	primitiveTypeUint8   primitiveTypeKind = "uint8"
-	primitiveTypeUint16  primitiveTypeKind = "uint16"
-	primitiveTypeUint32  primitiveTypeKind = "uint32"
-	primitiveTypeUint64  primitiveTypeKind = "uint64"
-	primitiveTypeInt8    primitiveTypeKind = "int8"
-	primitiveTypeInt16   primitiveTypeKind = "int16"
-	primitiveTypeInt32   primitiveTypeKind = "int32"
-	primitiveTypeInt64   primitiveTypeKind = "int64"
-	primitiveTypeFloat32 primitiveTypeKind = "float32"
-	primitiveTypeFloat64 primitiveTypeKind = "float64"
+	primitiveTypeBool      primitiveTypeKind = "bool"
+	primitiveTypeString    primitiveTypeKind = "string"
+	primitiveTypeInt       primitiveTypeKind = "int"
+	primitiveTypeUint      primitiveTypeKind = "uint"
+	primitiveTypeUint8     primitiveTypeKind = "uint8"
+	primitiveTypeUint16    primitiveTypeKind = "uint16"
+	primitiveTypeUint32    primitiveTypeKind = "uint32"
+	primitiveTypeUint64    primitiveTypeKind = "uint64"
+	primitiveTypeInt8      primitiveTypeKind = "int8"
+	primitiveTypeInt16     primitiveTypeKind = "int16"
+	primitiveTypeInt32     primitiveTypeKind = "int32"
+	primitiveTypeInt64     primitiveTypeKind = "int64"
+	primitiveTypeFloat32   primitiveTypeKind = "float32"
+	primitiveTypeFloat64   primitiveTypeKind = "float64"
+	primitiveTypeTime      primitiveTypeKind = "time"
+	primitiveTypeDuration  primitiveTypeKind = "duration"
+	primitiveTypeBytes     primitiveTypeKind = "bytes"
+	primitiveTypeBigNumber primitiveTypeKind = "bignumber"
 )
 
 func (t primitiveType) Equals(other Type) bool {
@@ -62,6 +66,14 @@ func (t primitiveType) FriendlyName() string {
 		return string(primitiveTypeFloat64)
 	case primitiveTypeString:
 		return string(primitiveTypeString)
+	case primitiveTypeTime:
+		return string(primitiveTypeTime)
+	case primitiveTypeDuration:
+		return string(primitiveTypeDuration)
+	case primitiveTypeBytes:
+		return string(primitiveTypeBytes)
+	case primitiveTypeBigNumber:
+		return string(primitiveTypeBigNumber)
 	default:
 		// should never happen
 		panic("invalid primitive type")
@@ -98,6 +110,14 @@ func (t primitiveType) GoString() string {
 		return "optional.Float32"
 	case primitiveTypeFloat64:
 		return "optional.Float64"
+	case primitiveTypeTime:
+		return "optional.Time"
+	case primitiveTypeDuration:
+		return "optional.Duration"
+	case primitiveTypeBytes:
+		return "optional.Bytes"
+	case primitiveTypeBigNumber:
+		return "optional.BigNumber"
 
 	default:
 		// should never happen
@@ -122,6 +142,19 @@ var String Type
 
 var Bool Type
 
+// Time is the type of points in time, held as time.Time.
+var Time Type
+
+// Duration is the type of time spans, held as time.Duration.
+var Duration Type
+
+// Bytes is the type of binary data, held as []byte.
+var Bytes Type
+
+// BigNumber is the type of arbitrary-precision numbers, held as *big.Float
+// with a mantissa of at least 512 bits.
+var BigNumber Type
+
 var True Value
 
 var False Value
@@ -175,6 +208,18 @@ func init() {
 	Bool = Type{
 		primitiveType{Kind: primitiveTypeBool},
 	}
+	Time = Type{
+		primitiveType{Kind: primitiveTypeTime},
+	}
+	Duration = Type{
+		primitiveType{Kind: primitiveTypeDuration},
+	}
+	Bytes = Type{
+		primitiveType{Kind: primitiveTypeBytes},
+	}
+	BigNumber = Type{
+		primitiveType{Kind: primitiveTypeBigNumber},
+	}
 
 	True = Value{
 		ty: Bool,
@@ -189,16 +234,22 @@ func init() {
 		v:  0,
 	}
 	PositiveInfinity = Value{
-		ty: Float64,
+		ty: BigNumber,
 		v:  (&big.Float{}).SetInf(false),
 	}
 	NegativeInfinity = Value{
-		ty: Float64,
+		ty: BigNumber,
 		v:  (&big.Float{}).SetInf(true),
 	}
 }
 
+// IsPrimitiveType returns true for the types of single values, decimal types
+// included.
 func (t Type) IsPrimitiveType() bool {
-	_, ok := t.typeImpl.(primitiveType)
-	return ok
+	switch t.typeImpl.(type) {
+	case primitiveType, typeDecimal:
+		return true
+	default:
+		return false
+	}
 }
@@ -1,9 +1,14 @@
 package optional
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestType_IsPrimitiveType(t *testing.T) {
@@ -15,6 +20,13 @@ func TestType_IsPrimitiveType(t *testing.T) {
 		{Int, true},
 		{Bool, true},
 		{StringMap(), false},
+		{ListType(String), false},
+		{ObjectType(map[string]Type{"name": String}), false},
+		{Time, true},
+		{Duration, true},
+		{Bytes, true},
+		{BigNumber, true},
+		{DecimalType(2), true},
 
 		// Make sure our primitive constants are correctly constructed
 		{True.Type(), true},
@@ -89,6 +101,565 @@ func TestConverter(t *testing.T) {
 
 }
 
+func TestTimeVal(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Value   Value
+		Layouts []string
+		Want    time.Time
+	}{
+		{TimeVal(day), nil, day},
+		{StringVal("2024-03-01T00:00:00Z"), nil, day},
+		{StringVal("2024-03-01"), nil, day},
+		{StringVal("1709251200"), nil, day},
+		{Int64Val(1709251200), nil, day},
+		{StringVal("1709251200000"), []string{TimeLayoutUnixMilli}, day},
+		{IntVal(1709251200000), []string{time.RFC3339, TimeLayoutUnixMilli}, day},
+		{StringVal("01/03/2024"), []string{"02/01/2006"}, day},
+	}
+	for _, test := range tests {
+		got, err := test.Value.Converter().Time(test.Layouts...)
+		if err != nil || !got.Equal(test.Want) {
+			t.Errorf("wrong result\nvalue: %v\ngot:  %v %v\nwant: %v", test.Value, got, err, test.Want)
+		}
+	}
+	if _, err := StringVal("yesterday").Converter().Time(); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+
+	if d, err := StringVal("1h30m").Converter().Duration(); err != nil || d != 90*time.Minute {
+		t.Errorf("wrong result\ngot:  %v %v", d, err)
+	}
+	if s, err := DurationVal(90 * time.Minute).Converter().String(); err != nil || s != "1h30m0s" {
+		t.Errorf("wrong result\ngot:  %v %v", s, err)
+	}
+
+	var form struct {
+		From    time.Time
+		To      *time.Time
+		Timeout time.Duration
+		Nanos   int64
+	}
+	val := MapStringVal(map[string]Value{
+		"from":    StringVal("2024-03-01"),
+		"to":      StringVal("2024-03-31T23:59:59Z"),
+		"timeout": StringVal("30s"),
+		"nanos":   DurationVal(time.Microsecond),
+	})
+	if err := val.UnMarshal(&form); err != nil {
+		t.Fatal(err)
+	}
+	if !form.From.Equal(day) || form.To == nil || form.To.Day() != 31 || form.Timeout != 30*time.Second || form.Nanos != 1000 {
+		t.Errorf("wrong result\ngot:  %+v", form)
+	}
+
+	err := val.Validates(
+		Validate("from", MustAfter(day.AddDate(0, 0, -1))),
+		Validate("to", MustBefore(day)),
+	).GetError()
+	if want := "to filed time must before 2024-03-01T00:00:00Z"; err == nil || err.Error() != want {
+		t.Errorf("wrong result\ngot:  %v\nwant: %s", err, want)
+	}
+}
+
+func TestBytesVal(t *testing.T) {
+	data := []byte{0xde, 0xad, 0xbe, 0xef}
+	if s, err := BytesVal(data).Converter().Base64(); err != nil || s != "3q2+7w==" {
+		t.Errorf("wrong result\ngot:  %s %v", s, err)
+	}
+	if s, err := BytesVal(data).Converter().Hex(); err != nil || s != "deadbeef" {
+		t.Errorf("wrong result\ngot:  %s %v", s, err)
+	}
+	if v, err := ParseHexVal("deadbeef"); err != nil || !v.Equals(BytesVal(data)) {
+		t.Errorf("wrong result\ngot:  %#v %v", v, err)
+	}
+	if _, err := ParseBase64Val("not base64!"); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+	if b, err := StringVal("raw").Converter().Bytes(); err != nil || string(b) != "raw" {
+		t.Errorf("wrong result\ngot:  %s %v", b, err)
+	}
+
+	sp := StringVal(" 3q2+7w== ").StringProcessor().TrimSpace().Base64StdDecode()
+	if err := sp.(*linkProcessor).GetError(); err != nil {
+		t.Fatal(err)
+	}
+	decoded := sp.(*linkProcessor).Value()
+	if !decoded.Equals(BytesVal(data)) {
+		t.Errorf("wrong result\ngot:  %#v", decoded)
+	}
+	if err := sp.ToUpper().(*linkProcessor).GetError(); err == nil {
+		t.Error("expected an error for a string operation on bytes")
+	}
+	if got := decoded.StringProcessor().HexEncode().(*linkProcessor).Value(); !got.Equals(StringVal("deadbeef")) {
+		t.Errorf("wrong result\ngot:  %#v", got)
+	}
+
+	var payload struct {
+		Data []byte
+		Text string
+	}
+	val := MapStringVal(map[string]Value{
+		"data": BytesVal(data),
+		"text": BytesVal([]byte("hello")),
+	})
+	if err := val.UnMarshal(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if string(payload.Data) != string(data) || payload.Text != "hello" {
+		t.Errorf("wrong result\ngot:  %+v", payload)
+	}
+
+	body := MapStringVal(map[string]Value{"doc": StringVal("eyJhIjoxfQ==")}).
+		Processors(Process("doc", Base64StdDecode())).Value()
+	if err := body.Validates(Validate("doc", MustIsJSON())).GetError(); err != nil {
+		t.Error(err)
+	}
+	err := StringVal("{").Validate("doc", MustIsJSON()).GetError()
+	if want := "doc filed value must is json"; err == nil || err.Error() != want {
+		t.Errorf("wrong result\ngot:  %v\nwant: %s", err, want)
+	}
+}
+
+func TestBigNumberVal(t *testing.T) {
+	if v, err := ParseFloat64Val("2.5"); err != nil || !v.Equals(Float64Val(2.5)) {
+		t.Errorf("wrong result\ngot:  %#v %v", v, err)
+	}
+	v, err := ParseBigNumberVal("0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := v.Converter().String(); err != nil || s != "0.1" {
+		t.Errorf("wrong result\ngot:  %s %v", s, err)
+	}
+	if f, err := v.Converter().BigFloat(); err != nil || f.Prec() != 512 {
+		t.Errorf("wrong result\ngot:  %v %v", f, err)
+	}
+	if f, err := v.Converter().Float64(); err != nil || f != 0.1 {
+		t.Errorf("wrong result\ngot:  %v %v", f, err)
+	}
+	huge, _ := ParseBigNumberVal("123456789012345678901234567890")
+	if i, err := huge.Converter().BigInt(); err != nil || i.String() != "123456789012345678901234567890" {
+		t.Errorf("wrong result\ngot:  %v %v", i, err)
+	}
+	if _, err := huge.Converter().Int64(); err == nil {
+		t.Error("expected an overflow error")
+	}
+	if _, err := v.Converter().Int(); err == nil {
+		t.Error("expected an error for a fraction")
+	}
+	if f, err := PositiveInfinity.Converter().BigFloat(); err != nil || !f.IsInf() || f.Signbit() {
+		t.Errorf("wrong result\ngot:  %v %v", f, err)
+	}
+	if f, err := NegativeInfinity.Converter().Float64(); err != nil || !math.IsInf(f, -1) {
+		t.Errorf("wrong result\ngot:  %v %v", f, err)
+	}
+	if f, err := IntVal(3).Converter().Float64(); err != nil || f != 3 {
+		t.Errorf("wrong result\ngot:  %v %v", f, err)
+	}
+	if u, err := StringVal("42").Converter().Uint(); err != nil || u != 42 {
+		t.Errorf("wrong result\ngot:  %v %v", u, err)
+	}
+}
+
+func TestDecimalVal(t *testing.T) {
+	tests := []struct {
+		In   string
+		Mode big.RoundingMode
+		Want string
+	}{
+		{"12.345", big.ToNearestAway, "12.35"},
+		{"12.345", big.ToNearestEven, "12.34"},
+		{"12.355", big.ToNearestEven, "12.36"},
+		{"-12.345", big.ToNearestAway, "-12.35"},
+		{"12.349", big.ToZero, "12.34"},
+		{"12.341", big.AwayFromZero, "12.35"},
+		{"-12.341", big.ToNegativeInf, "-12.35"},
+		{"-12.349", big.ToPositiveInf, "-12.34"},
+		{"0.005", big.ToNearestAway, "0.01"},
+		{"-0.5", big.ToNearestAway, "-0.50"},
+		{"7", big.ToNearestEven, "7.00"},
+		{"1/3", big.ToNearestEven, "0.33"},
+	}
+	for _, test := range tests {
+		v, err := ParseDecimalVal(test.In, 2, test.Mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := v.Converter().String(); got != test.Want {
+			t.Errorf("wrong result\nin:   %s %v\ngot:  %s\nwant: %s", test.In, test.Mode, got, test.Want)
+		}
+	}
+
+	price, _ := ParseDecimalVal("19.99", 2, big.ToNearestEven)
+	if !price.Type().Equals(DecimalType(2)) || price.Type().Equals(DecimalType(3)) {
+		t.Errorf("wrong result\ngot:  %#v", price.Type())
+	}
+	total, err := Int64Val(3).Converter().Decimal(2, big.ToNearestEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := price.Converter().BigRat()
+	n, _ := total.Converter().BigRat()
+	if got := DecimalVal(r.Mul(r, n), 2, big.ToNearestEven).String(); got != "59.97" {
+		t.Errorf("wrong result\ngot:  %s", got)
+	}
+	if _, err := price.Converter().Int(); err == nil {
+		t.Error("expected an error for a fraction")
+	}
+	if f, err := price.Converter().Float64(); err != nil || f != 19.99 {
+		t.Errorf("wrong result\ngot:  %v %v", f, err)
+	}
+	if b, err := price.Converter().JSON(); err != nil || string(b) != "19.99" {
+		t.Errorf("wrong result\ngot:  %s %v", b, err)
+	}
+	order := MapStringVal(map[string]Value{"price": price, "qty": IntVal(2)})
+	if b, err := order.Converter().JSON(); err != nil || string(b) != `{"price":19.99,"qty":2}` {
+		t.Errorf("wrong result\ngot:  %s %v", b, err)
+	}
+
+	var dst struct {
+		Price  *big.Rat
+		Amount big.Float
+		Qty    *big.Int
+		Text   string
+	}
+	val := MapStringVal(map[string]Value{
+		"price":  price,
+		"amount": StringVal("0.1"),
+		"qty":    StringVal("12345678901234567890"),
+		"text":   price,
+	})
+	if err := val.UnMarshal(&dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Price.RatString() != "1999/100" || dst.Amount.Text('g', -1) != "0.1" ||
+		dst.Qty.String() != "12345678901234567890" || dst.Text != "19.99" {
+		t.Errorf("wrong result\ngot:  %v %v %v %s", dst.Price, &dst.Amount, dst.Qty, dst.Text)
+	}
+}
+
+func TestUnify(t *testing.T) {
+	tests := []struct {
+		Types []Type
+		Want  Type
+		Err   bool
+	}{
+		{nil, DynamicPseudoType, false},
+		{[]Type{String, String}, String, false},
+		{[]Type{DynamicPseudoType, Bool}, Bool, false},
+		{[]Type{Int, Int8, Int64}, Int64, false},
+		{[]Type{Uint8, Uint32}, Uint64, false},
+		{[]Type{Int64, Uint}, BigNumber, false},
+		{[]Type{Int64, Float64}, Float64, false},
+		{[]Type{DecimalType(2), Int, DecimalType(4)}, DecimalType(4), false},
+		{[]Type{DecimalType(2), Float64}, BigNumber, false},
+		{[]Type{ListType(Int64), ListType(Float64)}, ListType(Float64), false},
+		{
+			[]Type{
+				ObjectType(map[string]Type{"id": Int64, "name": String}),
+				ObjectType(map[string]Type{"id": Float64}),
+			},
+			ObjectTypeWithOptionalAttrs(map[string]Type{"id": Float64, "name": String}, []string{"name"}),
+			false,
+		},
+		{[]Type{String, Int64}, DynamicPseudoType, true},
+		{[]Type{ListType(String), String}, DynamicPseudoType, true},
+	}
+	for _, test := range tests {
+		got, err := Unify(test.Types...)
+		if (err != nil) != test.Err || !got.Equals(test.Want) {
+			t.Errorf("wrong result\ntypes: %#v\ngot:   %#v %v\nwant:  %#v", test.Types, got, err, test.Want)
+		}
+	}
+
+	val, err := ObjectVal(map[string]Value{"id": Int64Val(7)}).Converter().To(
+		ObjectTypeWithOptionalAttrs(map[string]Type{"id": Float64, "name": String}, []string{"name"}))
+	if err != nil || !val.GetMapValue("id").Equals(Float64Val(7)) || !val.GetMapValue("name").IsNull() {
+		t.Errorf("wrong result\ngot:  %#v %v", val, err)
+	}
+	counts, err := MapStringVal(map[string]Value{"a": StringVal("1"), "b": IntVal(2)}).Converter().To(StringMapOf(Int64))
+	if err != nil || !counts.GetMapValue("a").Equals(Int64Val(1)) || !counts.GetMapValue("b").Equals(Int64Val(2)) {
+		t.Errorf("wrong result\ngot:  %#v %v", counts, err)
+	}
+	typed, err := ObjectVal(map[string]Value{"id": IntVal(7), "name": StringVal("go")}).Converter().To(
+		StringMapType(map[string]Type{"id": String}))
+	if err != nil || !typed.GetMapValue("id").Equals(StringVal("7")) || !typed.GetMapValue("name").Equals(StringVal("go")) {
+		t.Errorf("wrong result\ngot:  %#v %v", typed, err)
+	}
+	if _, err := MapStringVal(map[string]Value{"a": StringVal("x")}).Converter().To(StringMapOf(Int64)); err == nil {
+		t.Error("expected a conversion error")
+	}
+	if _, err := StringVal("x").Converter().To(Int64); err == nil {
+		t.Error("expected a conversion error")
+	}
+	if val, err := NullVal(DynamicPseudoType).Converter().To(String); err != nil || !val.IsNull() || val.Type() != String {
+		t.Errorf("wrong result\ngot:  %#v %v", val, err)
+	}
+
+	list := unifiedListVal([]Value{IntVal(1), Float64Val(2.5), NullVal(DynamicPseudoType)})
+	if !list.Type().Equals(ListType(Float64)) || !list.GetListValue(0).Equals(Float64Val(1)) {
+		t.Errorf("wrong result\ngot:  %#v", list)
+	}
+	wide := unifiedListVal([]Value{Uint64Val(math.MaxUint64), Int64Val(-1)})
+	if err := wide.GetError(); err != nil || !wide.Type().Equals(ListType(BigNumber)) {
+		t.Errorf("wrong result\ngot:  %#v %v", wide, err)
+	}
+	for i, want := range []string{"18446744073709551615", "-1"} {
+		if n, err := wide.GetListValue(i).Converter().BigInt(); err != nil || n.String() != want {
+			t.Errorf("wrong result\ngot:  %v %v\nwant: %s", n, err, want)
+		}
+	}
+	mixed := unifiedListVal([]Value{IntVal(1), StringVal("a")})
+	if !mixed.Type().Equals(ListType(DynamicPseudoType)) || !mixed.GetListValue(1).Equals(StringVal("a")) {
+		t.Errorf("wrong result\ngot:  %#v", mixed)
+	}
+}
+
+func TestTypeJSON(t *testing.T) {
+	tests := []struct {
+		Type Type
+		Want string
+	}{
+		{String, `"string"`},
+		{Uint16, `"uint16"`},
+		{BigNumber, `"bignumber"`},
+		{DynamicPseudoType, `"dynamic"`},
+		{File, `"file"`},
+		{DecimalType(2), `["decimal",2]`},
+		{ListType(Int64), `["list","int64"]`},
+		{StringMap(), `["map",{}]`},
+		{StringMapOf(ListType(String)), `["map",["list","string"]]`},
+		{StringMapType(map[string]Type{"name": String, "age": Int}), `["map",{"age":"int","name":"string"}]`},
+		{Type{typeStringMap{AttrType: map[string]Type{"id": Int64}, ElemType: String}}, `["map",{"id":"int64"},"string"]`},
+		{ObjectType(map[string]Type{"id": Int64, "tags": ListType(String)}), `["object",{"id":"int64","tags":["list","string"]}]`},
+		{
+			ObjectTypeWithOptionalAttrs(map[string]Type{"id": Int64, "email": String, "name": String}, []string{"name", "email"}),
+			`["object",{"email":"string","id":"int64","name":"string"},["email","name"]]`,
+		},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.Type)
+		if err != nil || string(b) != test.Want {
+			t.Errorf("wrong result\ngot:  %s %v\nwant: %s", b, err, test.Want)
+			continue
+		}
+		var got Type
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Errorf("wrong result\ninput: %s\nerr:   %v", b, err)
+			continue
+		}
+		// typeStringMap.Equals ignores attributes, compare the encodings too
+		again, _ := json.Marshal(got)
+		if !got.Equals(test.Type) || string(again) != test.Want {
+			t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Type)
+		}
+	}
+
+	for _, input := range []string{`"integer"`, `["list"]`, `["decimal",-1]`, `["set","int"]`, `["object",{"id":"int"},["name"]]`, `1`} {
+		var ty Type
+		if err := json.Unmarshal([]byte(input), &ty); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+	if _, err := json.Marshal(Type{}); err == nil {
+		t.Error("expected an error for an invalid type")
+	}
+}
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		Input string
+		Want  Type
+	}{
+		{"string", String},
+		{" time ", Time},
+		{"decimal(2)", DecimalType(2)},
+		{"list(int64)", ListType(Int64)},
+		{"list( list(bool) )", ListType(ListType(Bool))},
+		{"map", StringMap()},
+		{"map(string)", StringMapOf(String)},
+		{"map({name=string, age=int})", StringMapType(map[string]Type{"name": String, "age": Int})},
+		{"object({})", ObjectType(map[string]Type{})},
+		{
+			"object({id=int64, name=optional(string), tags=list(string)})",
+			ObjectTypeWithOptionalAttrs(map[string]Type{"id": Int64, "name": String, "tags": ListType(String)}, []string{"name"}),
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseType(test.Input)
+		if err != nil {
+			t.Errorf("wrong result\ninput: %s\nerr:   %v", test.Input, err)
+			continue
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(test.Want)
+		if !got.Equals(test.Want) || string(gotJSON) != string(wantJSON) {
+			t.Errorf("wrong result\ninput: %s\ngot:   %s\nwant:  %s", test.Input, gotJSON, wantJSON)
+		}
+	}
+
+	if ty, _ := ParseType("map(int)"); ty.Equals(StringMapOf(String)) || ty.Equals(StringMap()) || !ty.Equals(StringMapOf(Int)) {
+		t.Errorf("wrong result\ngot:  %#v", ty)
+	}
+
+	for _, input := range []string{"", "integer", "list", "list(int", "map({a=int,a=int})", "map({a=optional(int)})", "decimal(x)", "string int"} {
+		if _, err := ParseType(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	at := time.Date(2024, 5, 1, 8, 30, 0, 500, time.UTC)
+	price, _ := ParseDecimalVal("19.90", 2, big.ToNearestEven)
+	num, _ := ParseBigNumberVal("1.00000000000000000000000000001")
+	tests := []struct {
+		Value Value
+		Want  string
+	}{
+		{StringVal("a\"b"), `"a\"b"`},
+		{BoolVal(true), `true`},
+		{Int8Val(-3), `-3`},
+		{Int64Val(1 << 60), `1152921504606846976`},
+		{Uint64Val(math.MaxUint64), `18446744073709551615`},
+		{Float32Val(1.5), `1.5`},
+		{Float64Val(0.1), `0.1`},
+		{TimeVal(at), `"2024-05-01T08:30:00.0000005Z"`},
+		{DurationVal(90 * time.Minute), `"1h30m0s"`},
+		{BytesVal([]byte("go")), `"Z28="`},
+		{price, `19.90`},
+		{num, `1.00000000000000000000000000001`},
+		{PositiveInfinity, `"+Inf"`},
+		{NullVal(Int64), `null`},
+		{ListValEmpty(String), `[]`},
+		{ListVal([]Value{Int64Val(1), NullVal(Int64)}), `[1,null]`},
+		{MapStringVal(map[string]Value{"b": IntVal(2), "a": StringVal("x")}), `{"a":"x","b":2}`},
+		{
+			ObjectVal(map[string]Value{
+				"id":    Int64Val(7),
+				"tags":  ListVal([]Value{StringVal("go")}),
+				"owner": ObjectVal(map[string]Value{"since": TimeVal(at)}),
+			}),
+			`{"id":7,"owner":{"since":"2024-05-01T08:30:00.0000005Z"},"tags":["go"]}`,
+		},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.Value)
+		if err != nil || string(b) != test.Want {
+			t.Errorf("wrong result\ngot:  %s %v\nwant: %s", b, err, test.Want)
+			continue
+		}
+		got, err := UnmarshalJSON(b, test.Value.Type())
+		if err != nil {
+			t.Errorf("wrong result\ninput: %s\nerr:   %v", b, err)
+			continue
+		}
+		again, _ := json.Marshal(got)
+		if !got.Type().Equals(test.Value.Type()) || string(again) != test.Want {
+			t.Errorf("wrong result\ngot:  %#v %s\nwant: %#v", got.Type(), again, test.Value.Type())
+		}
+	}
+
+	b, err := Uint64Val(math.MaxUint64).Converter().JSON(WithJSONInt64String())
+	if err != nil || string(b) != `"18446744073709551615"` {
+		t.Errorf("wrong result\ngot:  %s %v", b, err)
+	}
+	if got, err := UnmarshalJSON(b, Uint64); err != nil || !got.Equals(Uint64Val(math.MaxUint64)) {
+		t.Errorf("wrong result\ngot:  %#v %v", got, err)
+	}
+	if b, _ := IntVal(3).Converter().JSON(WithJSONInt64String()); string(b) != `3` {
+		t.Errorf("wrong result\ngot:  %s", b)
+	}
+	if got, err := UnmarshalJSON([]byte(`1500000000`), Duration); err != nil || !got.Equals(DurationVal(1500*time.Millisecond)) {
+		t.Errorf("wrong result\ngot:  %#v %v", got, err)
+	}
+	if _, err := Float64Val(math.NaN()).Converter().JSON(); err == nil {
+		t.Error("expected an error for NaN")
+	}
+
+	schema := ObjectTypeWithOptionalAttrs(map[string]Type{"id": Int64, "email": String}, []string{"email"})
+	errTests := []struct {
+		Input string
+		Type  Type
+		Want  string
+	}{
+		{`300`, Uint8, "uint8 is required"},
+		{`1.5`, Int, "int is required"},
+		{`"1"`, Bool, "json string can not be a value of type bool"},
+		{`1`, String, "json number can not be a value of type string"},
+		{`[1,"x"]`, ListType(Int64), "element 1: int64 is required"},
+		{`{}`, schema, `attribute "id" is required`},
+		{`{"id":1,"name":"x"}`, schema, `unexpected attribute "name"`},
+		{`{"id":1} 2`, schema, "invalid data after top-level json value"},
+	}
+	for _, test := range errTests {
+		_, err := UnmarshalJSON([]byte(test.Input), test.Type)
+		if err == nil || err.Error() != test.Want {
+			t.Errorf("wrong result\ninput: %s\ngot:   %v\nwant:  %s", test.Input, err, test.Want)
+		}
+	}
+	if val, err := UnmarshalJSON([]byte(`{"id":"7"}`), schema); err != nil || !val.GetMapValue("id").Equals(Int64Val(7)) {
+		t.Errorf("wrong result\ngot:  %#v %v", val, err)
+	}
+	if val, err := UnmarshalJSON([]byte(`{"a":[1,2.5]}`), StringMap()); err != nil ||
+		!val.GetMapValue("a").Type().Equals(ListType(Float64)) {
+		t.Errorf("wrong result\ngot:  %#v %v", val, err)
+	}
+}
+
+func TestSimpleJSONValue(t *testing.T) {
+	val := ObjectVal(map[string]Value{
+		"id":    Uint64Val(7),
+		"at":    TimeVal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)),
+		"names": ListValEmpty(String),
+	})
+	b, err := json.Marshal(SimpleJSONValue{Value: val})
+	want := `{"value":{"at":"2024-05-01T00:00:00Z","id":7,"names":[]},"type":["object",{"at":"time","id":"uint64","names":["list","string"]}]}`
+	if err != nil || string(b) != want {
+		t.Fatalf("wrong result\ngot:  %s %v\nwant: %s", b, err, want)
+	}
+	var got SimpleJSONValue
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Value.Type().Equals(val.Type()) || !got.Value.GetMapValue("id").Equals(Uint64Val(7)) ||
+		!got.Value.GetMapValue("at").Equals(val.GetMapValue("at")) {
+		t.Errorf("wrong result\ngot:  %#v", got.Value)
+	}
+	if err := json.Unmarshal([]byte(`{"value":1}`), &got); err == nil {
+		t.Error("expected an error for a missing type")
+	}
+
+	// values where the type is dynamic keep their own type
+	at := TimeVal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	mixed, err := ObjectVal(map[string]Value{
+		"list": unifiedListVal([]Value{at, Int8Val(-3), DurationVal(time.Minute), NullVal(Int8)}),
+		"any":  DurationVal(time.Second),
+		"id":   Int64Val(9007199254740993),
+	}).Converter().To(ObjectType(map[string]Type{
+		"list": ListType(DynamicPseudoType),
+		"any":  DynamicPseudoType,
+		"id":   Int64,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = json.Marshal(SimpleJSONValue{Value: mixed, Int64String: true})
+	want = `{"value":{"any":["duration","1s"],"id":"9007199254740993","list":[["time","2024-05-01T00:00:00Z"],["int8",-3],["duration","1m0s"],["int8",null]]},`
+	if err != nil || !strings.HasPrefix(string(b), want) {
+		t.Errorf("wrong result\ngot:  %s %v\nwant: %s...", b, err, want)
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"list", "any", "id"} {
+		if !got.Value.GetMapValue(name).Equals(mixed.GetMapValue(name)) {
+			t.Errorf("wrong result\nname: %s\ngot:  %#v\nwant: %#v", name, got.Value.GetMapValue(name), mixed.GetMapValue(name))
+		}
+	}
+}
+
 func TestValidator(t *testing.T) {
 	tests := []struct {
 		Value Value
@@ -121,8 +692,133 @@ func TestValidator(t *testing.T) {
 	}
 }
 
+func TestListVal(t *testing.T) {
+	list := ListVal([]Value{StringVal("1"), StringVal("2"), StringVal("3")})
+	if err := list.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if !list.Type().Equals(ListType(String)) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", list.Type(), ListType(String))
+	}
+	if got, want := list.Type().FriendlyName(), "list of string"; got != want {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+	}
+	if got, want := fmt.Sprintf("%#v", list.Type()), "optional.ListType(optional.String)"; got != want {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+	}
+	if !list.Type().ElementType().Equals(String) {
+		t.Errorf("wrong element type %#v", list.Type().ElementType())
+	}
+	if err := ListVal([]Value{StringVal("1"), IntVal(2)}).GetError(); err == nil {
+		t.Error("expected error for inconsistent element types")
+	}
+	if s, err := list.Converter().String(); err != nil || s != `["1","2","3"]` {
+		t.Errorf("wrong result\n Func: %s\ngot %s\n err:%v", "List String()", s, err)
+	}
+
+	var ints []int
+	if err := list.UnMarshal(&ints); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ints) != "[1 2 3]" {
+		t.Errorf("wrong result\ngot:  %v\nwant: [1 2 3]", ints)
+	}
+
+	if err := list.Validate("ids", MustEach(MustIsDigit())).GetError(); err != nil {
+		t.Error(err)
+	}
+	if err := ListVal([]Value{StringVal("1"), StringVal("x")}).
+		Validate("ids", MustEach(MustIsDigit())).GetError(); err == nil {
+		t.Error("expected error for non digit element")
+	}
+
+	value := MapStringVal(map[string]Value{"ids": list}).
+		Processors(Process("ids", Each(ToInt()))).Value()
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if ty := value.GetMapValue("ids").Type(); !ty.Equals(ListType(Int)) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", ty, ListType(Int))
+	}
+}
+
+func TestObjectVal(t *testing.T) {
+	user := ObjectVal(map[string]Value{
+		"name": StringVal("gorpher"),
+		"address": ObjectVal(map[string]Value{
+			"city": StringVal("chengdu"),
+			"tags": ListVal([]Value{StringVal("home")}),
+		}),
+	})
+	if err := user.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	want := ObjectType(map[string]Type{
+		"name": String,
+		"address": ObjectType(map[string]Type{
+			"city": String,
+			"tags": ListType(String),
+		}),
+	})
+	if !user.Type().Equals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", user.Type(), want)
+	}
+	if other := ObjectType(map[string]Type{"name": String, "address": ObjectType(nil)}); user.Type().Equals(other) {
+		t.Errorf("types must not be equal\ngot:  %#v\nwant: %#v", user.Type(), other)
+	}
+	if got, want := user.Type().FriendlyName(),
+		"object of [(address=object of [(city=string)(tags=list of string)])(name=string)]"; got != want {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+	}
+	city := user.GetMapValue("address").GetMapValue("city")
+	if !city.Equals(StringVal("chengdu")) {
+		t.Errorf("wrong result\ngot:  %#v", city)
+	}
+
+	schema := ObjectTypeWithOptionalAttrs(map[string]Type{"name": String, "email": String}, []string{"email"})
+	if !schema.AttributeOptional("email") || schema.AttributeOptional("name") {
+		t.Errorf("wrong optional attributes %#v", schema)
+	}
+	value := ObjectValWithType(map[string]Value{"name": StringVal("gorpher")}, schema)
+	if err := value.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ObjectValWithType(map[string]Value{"email": StringVal("a@b.c")}, schema).GetError(); err == nil {
+		t.Error("expected error for missing required attribute")
+	}
+	if err := ObjectValWithType(map[string]Value{"name": IntVal(1)}, schema).GetError(); err == nil {
+		t.Error("expected error for wrong attribute type")
+	}
+	withAge := value.SetMapValue("age", IntVal(24))
+	if schema.HasAttribute("age") {
+		t.Errorf("schema must not be changed %#v", schema)
+	}
+	if !withAge.Type().HasAttribute("age") || !withAge.Type().AttributeOptional("email") {
+		t.Errorf("wrong result\ngot: %#v", withAge.Type())
+	}
+	if err := value.Validates(
+		Validate("name", MustIsLetter()),
+		Validate("email", MustHasString("@")),
+	).GetError(); err != nil {
+		t.Errorf("optional attribute must not fail: %v", err)
+	}
+	if err := user.Validates(Validate("email", MustHasString("@"))).GetError(); err == nil {
+		t.Error("expected error for missing required attribute")
+	}
+	if err := user.Validates(Validate("email", MustHasString("@")).Optional()).GetError(); err != nil {
+		t.Errorf("optional validator must not fail: %v", err)
+	}
+}
+
 func TestProcessor(t *testing.T) {
-	//todo
+	user := ObjectVal(map[string]Value{"name": StringVal("gorpher")})
+	var name string
+	if err := user.Processors(Process("name", ToUpper())).Aligns(Align("name", &name)); err != nil {
+		t.Fatal(err)
+	}
+	if name != "GORPHER" {
+		t.Errorf("wrong result\ngot: %s\nwant: %s", name, "GORPHER")
+	}
 }
 
 func TestAlign(t *testing.T) {
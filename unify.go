@@ -0,0 +1,333 @@
+package optional
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Unify returns the type that values of all the given types can be
+// converted to, see converter.To:
+//
+//   - equal types unify to themselves
+//   - DynamicPseudoType unifies with any type, it is skipped
+//   - integers unify to Int64, or Uint64 if they are all unsigned; signed
+//     and unsigned integers unify to BigNumber, which holds both exactly
+//   - integers and floats unify to Float64
+//   - decimals unify with integers and decimals to the largest scale
+//   - any other mix of numbers unifies to BigNumber
+//   - lists unify if their element types do
+//   - objects unify attribute by attribute; an attribute missing from some
+//     of them becomes optional
+//
+// Unify returns DynamicPseudoType if there are no types to unify, or if all
+// of them are DynamicPseudoType. Any other mix of types, such as strings and
+// numbers, has no common type and is an error.
+func Unify(types ...Type) (Type, error) {
+	var known []Type
+	for _, ty := range types {
+		if ty.typeImpl == nil {
+			return DynamicPseudoType, fmt.Errorf("can not unify an invalid type")
+		}
+		if !ty.IsDynamicType() {
+			known = append(known, ty)
+		}
+	}
+	if len(known) == 0 {
+		return DynamicPseudoType, nil
+	}
+	same := true
+	for _, ty := range known[1:] {
+		if !ty.Equals(known[0]) {
+			same = false
+			break
+		}
+	}
+	switch {
+	case same && !known[0].IsObjectType():
+		return known[0], nil
+	case allTypes(known, Type.isNumberType):
+		return unifyNumbers(known), nil
+	case allTypes(known, Type.IsListType):
+		elems := make([]Type, len(known))
+		for i := range known {
+			elems[i] = known[i].ElementType()
+		}
+		elem, err := Unify(elems...)
+		if err != nil {
+			return DynamicPseudoType, err
+		}
+		return ListType(elem), nil
+	case allTypes(known, Type.IsObjectType):
+		return unifyObjects(known)
+	}
+	return DynamicPseudoType, fmt.Errorf("%s and %s have no common type", known[0].FriendlyName(), firstOther(known).FriendlyName())
+}
+
+func allTypes(types []Type, is func(t Type) bool) bool {
+	for _, ty := range types {
+		if !is(ty) {
+			return false
+		}
+	}
+	return true
+}
+
+func firstOther(types []Type) Type {
+	for _, ty := range types[1:] {
+		if !ty.Equals(types[0]) {
+			return ty
+		}
+	}
+	return types[0]
+}
+
+func (t Type) isNumberType() bool {
+	return Value{ty: t}.isNumber()
+}
+
+func unifyNumbers(types []Type) Type {
+	var signed, unsigned, floats, decimals, bigs bool
+	scale := 0
+	for _, ty := range types {
+		switch {
+		case ty.IsDecimalType():
+			decimals = true
+			if s := ty.DecimalScale(); s > scale {
+				scale = s
+			}
+		case ty.Equals(BigNumber):
+			bigs = true
+		case ty.Equals(Float32) || ty.Equals(Float64):
+			floats = true
+		case ty.Equals(Uint) || ty.Equals(Uint8) || ty.Equals(Uint16) || ty.Equals(Uint32) || ty.Equals(Uint64):
+			unsigned = true
+		default:
+			signed = true
+		}
+	}
+	switch {
+	case bigs || (decimals && floats):
+		return BigNumber
+	case decimals:
+		return DecimalType(scale)
+	case floats:
+		return Float64
+	case unsigned && !signed:
+		return Uint64
+	case unsigned && signed:
+		return BigNumber
+	default:
+		return Int64
+	}
+}
+
+func unifyObjects(types []Type) (Type, error) {
+	attrs := map[string][]Type{}
+	for _, ty := range types {
+		for name, attr := range ty.AttributeTypes() {
+			attrs[name] = append(attrs[name], attr)
+		}
+	}
+	attrTypes := make(map[string]Type, len(attrs))
+	var optional []string
+	for name := range attrs {
+		attr, err := Unify(attrs[name]...)
+		if err != nil {
+			return DynamicPseudoType, fmt.Errorf("attribute %q: %w", name, err)
+		}
+		attrTypes[name] = attr
+		if len(attrs[name]) < len(types) || anyOptional(types, name) {
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(optional)
+	return ObjectTypeWithOptionalAttrs(attrTypes, optional), nil
+}
+
+func anyOptional(types []Type, name string) bool {
+	for _, ty := range types {
+		if ty.HasAttribute(name) && ty.AttributeOptional(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// unifiedListVal returns a list of the given values converted to their
+// unified type. Values without a common type, or that do not all convert to
+// it, make a list of DynamicPseudoType, each element keeping its own type.
+func unifiedListVal(vals []Value) Value {
+	types := make([]Type, len(vals))
+	for i := range vals {
+		if err := vals[i].GetError(); err != nil {
+			return Value{err: err}
+		}
+		types[i] = vals[i].ty
+	}
+	dynamic := Value{
+		ty: ListType(DynamicPseudoType),
+		v:  append([]Value(nil), vals...),
+	}
+	ty, err := Unify(types...)
+	if err != nil || ty.IsDynamicType() {
+		return dynamic
+	}
+	elems := make([]Value, len(vals))
+	for i := range vals {
+		elem, err := vals[i].Converter().To(ty)
+		if err != nil {
+			return dynamic
+		}
+		elems[i] = elem
+	}
+	return Value{
+		ty: ListType(ty),
+		v:  elems,
+	}
+}
+
+// To converts the value to the given type, such as the type returned by
+// Unify. A null value becomes a null of the type, and any value converts to
+// DynamicPseudoType unchanged. Numbers converted to a decimal type are
+// rounded half to even.
+func (c converter) To(ty Type) (Value, error) {
+	val := c.value
+	if err := val.GetError(); err != nil {
+		return val, err
+	}
+	if ty.typeImpl == nil {
+		return NilVal, ConvertError
+	}
+	if ty.IsDynamicType() || (val.ty.typeImpl != nil && val.ty.Equals(ty) && !ty.IsObjectType() && !ty.IsMapType()) {
+		return val, nil
+	}
+	if val.IsNull() {
+		return NullVal(ty), nil
+	}
+	switch {
+	case ty.IsListType():
+		return c.toList(ty)
+	case ty.IsObjectType():
+		return c.toObject(ty)
+	case ty.IsMapType():
+		return c.toMap(ty)
+	case ty.IsDecimalType():
+		return c.Decimal(ty.DecimalScale(), big.ToNearestEven)
+	case ty.IsPrimitiveType():
+		return c.toPrimitive(ty)
+	}
+	return NilVal, ConvertError
+}
+
+func (c converter) toPrimitive(ty Type) (Value, error) {
+	var v interface{}
+	var err error
+	switch ty {
+	case String:
+		v, err = c.String()
+	case Bool:
+		v, err = c.Bool()
+	case Int:
+		v, err = c.Int()
+	case Int8:
+		v, err = c.Int8()
+	case Int16:
+		v, err = c.Int16()
+	case Int32:
+		v, err = c.Int32()
+	case Int64:
+		v, err = c.Int64()
+	case Uint:
+		v, err = c.Uint()
+	case Uint8:
+		v, err = c.Uint8()
+	case Uint16:
+		v, err = c.Uint16()
+	case Uint32:
+		v, err = c.Uint32()
+	case Uint64:
+		v, err = c.Uint64()
+	case Float32:
+		v, err = c.Float32()
+	case Float64:
+		v, err = c.Float64()
+	case Time:
+		v, err = c.Time()
+	case Duration:
+		v, err = c.Duration()
+	case Bytes:
+		v, err = c.Bytes()
+	case BigNumber:
+		v, err = c.BigFloat()
+	default:
+		return NilVal, ConvertError
+	}
+	if err != nil {
+		return NilVal, err
+	}
+	return Value{ty: ty, v: v}, nil
+}
+
+func (c converter) toList(ty Type) (Value, error) {
+	elems, err := c.List()
+	if err != nil {
+		return NilVal, err
+	}
+	res := make([]Value, len(elems))
+	for i := range elems {
+		res[i], err = elems[i].Converter().To(ty.ElementType())
+		if err != nil {
+			return NilVal, err
+		}
+	}
+	return Value{ty: ty, v: res}, nil
+}
+
+// toMap converts each key of a map or object value to its type in the map
+// type ty, see GetStringMapType. Keys ty has no type for are kept as they
+// are.
+func (c converter) toMap(ty Type) (Value, error) {
+	if !c.value.IsObjectValue() && !c.value.IsMapValue() {
+		return NilVal, ConvertError
+	}
+	tm := ty.typeImpl.(typeStringMap)
+	names := c.value.attrNames()
+	rawMap := make(map[string]interface{}, len(names))
+	types := make(map[string]Type, len(names))
+	for _, name := range names {
+		attr := c.value.GetMapValue(name)
+		if attrType := tm.GetStringMapType(name); attrType.typeImpl != nil {
+			var err error
+			if attr, err = attr.Converter().To(attrType); err != nil {
+				return NilVal, fmt.Errorf("attribute %q: %w", name, err)
+			}
+		}
+		rawMap[name] = attr.v
+		types[name] = attr.ty
+	}
+	return Value{ty: Type{typeStringMap{AttrType: types, ElemType: tm.ElemType}}, v: rawMap}, nil
+}
+
+func (c converter) toObject(ty Type) (Value, error) {
+	if !c.value.IsObjectValue() && !c.value.IsMapValue() {
+		return NilVal, ConvertError
+	}
+	res := make(map[string]Value, len(ty.AttributeTypes()))
+	for _, name := range c.value.attrNames() {
+		if !ty.HasAttribute(name) {
+			return NilVal, fmt.Errorf("unexpected attribute %q", name)
+		}
+		attr, err := c.value.GetMapValue(name).Converter().To(ty.AttributeType(name))
+		if err != nil {
+			return NilVal, fmt.Errorf("attribute %q: %w", name, err)
+		}
+		res[name] = attr
+	}
+	for name := range ty.AttributeTypes() {
+		if _, ok := res[name]; !ok && !ty.AttributeOptional(name) {
+			return NilVal, fmt.Errorf("attribute %q is required", name)
+		}
+	}
+	return Value{ty: ty, v: res}, nil
+}
@@ -1,9 +1,13 @@
 package optional
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"time"
 )
 
 // BoolVal returns a Value of type Number whose internal value is the given
@@ -15,11 +19,41 @@ func BoolVal(v bool) Value {
 	}
 }
 
-// ParseNumberVal returns a Value of type number produced by parsing the given
-// string as a decimal real number. To ensure that two identical strings will
-// always produce an equal number, always use this function to derive a number
-// from a string; it will ensure that the precision and rounding mode for the
-// internal big decimal is configured in a consistent way.
+// ParseFloat64Val returns a Value of type Float64 produced by parsing the
+// given string as a decimal real number, rounded to the nearest float64.
+//
+// If the given string cannot be parsed as a number, the returned error has
+// the message "a number is required", making it suitable to return to an
+// end-user to signal a type conversion error.
+func ParseFloat64Val(s string) (Value, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return NilVal, fmt.Errorf("a number is required")
+	}
+	return Float64Val(f), nil
+}
+
+// bigNumberPrec is the mantissa precision of BigNumber values in bits.
+const bigNumberPrec = 512
+
+// BigNumberVal returns a Value of type BigNumber holding a copy of f. The
+// precision of the copy is at least 512 bits.
+func BigNumberVal(f *big.Float) Value {
+	prec := f.Prec()
+	if prec < bigNumberPrec {
+		prec = bigNumberPrec
+	}
+	return Value{
+		ty: BigNumber,
+		v:  new(big.Float).SetPrec(prec).Set(f),
+	}
+}
+
+// ParseBigNumberVal returns a Value of type BigNumber produced by parsing the
+// given string as a decimal real number. To ensure that two identical
+// strings will always produce an equal number, always use this function to
+// derive a number from a string; it will ensure that the precision and
+// rounding mode for the internal big float is configured in a consistent way.
 //
 // If the given string cannot be parsed as a number, the returned error has
 // the message "a number is required", making it suitable to return to an
@@ -28,15 +62,16 @@ func BoolVal(v bool) Value {
 // If the given string contains a number that becomes a recurring fraction
 // when expressed in binary then it will be truncated to have a 512-bit
 // mantissa. Note that this is a higher precision than that of a float64,
-// so coverting the same decimal number first to float64 and then calling
-// NumberFloatVal will not produce an equal result; the conversion first
-// to float64 will round the mantissa to fewer than 512 bits.
-func ParseFloat64Val(s string) (Value, error) {
-	int64, err := strconv.ParseInt(s, 10, 64)
+// so converting the same decimal number first to float64 and then calling
+// BigNumberVal will not produce an equal result; the conversion first to
+// float64 will round the mantissa to fewer than 512 bits. Use a decimal
+// type, see ParseDecimalVal, for numbers that must be exact.
+func ParseBigNumberVal(s string) (Value, error) {
+	f, _, err := big.ParseFloat(s, 10, bigNumberPrec, big.ToNearestEven)
 	if err != nil {
 		return NilVal, fmt.Errorf("a number is required")
 	}
-	return Value{ty: Float64, v: int64}, nil
+	return Value{ty: BigNumber, v: f}, nil
 }
 
 func IntVal(v int) Value {
@@ -112,6 +147,51 @@ func Float64Val(v float64) Value {
 	}
 }
 
+// TimeVal returns a Value of type Time holding the given point in time.
+func TimeVal(v time.Time) Value {
+	return Value{
+		ty: Time,
+		v:  v,
+	}
+}
+
+// DurationVal returns a Value of type Duration holding the given time span.
+func DurationVal(v time.Duration) Value {
+	return Value{
+		ty: Duration,
+		v:  v,
+	}
+}
+
+// BytesVal returns a Value of type Bytes holding the given binary data. The
+// slice is not copied.
+func BytesVal(v []byte) Value {
+	return Value{
+		ty: Bytes,
+		v:  v,
+	}
+}
+
+// ParseBase64Val returns a Value of type Bytes holding the data of the given
+// standard base64 encoded string.
+func ParseBase64Val(s string) (Value, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return NilVal, fmt.Errorf("a base64 string is required")
+	}
+	return BytesVal(b), nil
+}
+
+// ParseHexVal returns a Value of type Bytes holding the data of the given hex
+// encoded string.
+func ParseHexVal(s string) (Value, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return NilVal, fmt.Errorf("a hex string is required")
+	}
+	return BytesVal(b), nil
+}
+
 // NullVal returns a null value of the given type. A null can be created of any
 // type, but operations on such values will always panic. Calling applications
 // are encouraged to use nulls only sparingly, particularly when user-provided
@@ -162,3 +242,88 @@ func MapStringValEmpty() Value {
 		v:  map[string]interface{}{},
 	}
 }
+
+// ListVal returns a Value of list type whose element type is the type of the
+// given values, which must all be of the same type.
+func ListVal(vals []Value) Value {
+	if len(vals) == 0 {
+		return Value{err: errors.New("must not call ListVal with empty slice")}
+	}
+	elemType := vals[0].ty
+	rawList := make([]Value, len(vals))
+	for i, val := range vals {
+		if err := val.GetError(); err != nil {
+			return Value{err: err}
+		}
+		if val.ty.typeImpl == nil || !val.ty.Equals(elemType) {
+			return Value{err: fmt.Errorf("inconsistent list element types at index %d", i)}
+		}
+		rawList[i] = val
+	}
+	return Value{
+		ty: ListType(elemType),
+		v:  rawList,
+	}
+}
+
+// ListValEmpty returns an empty list of the given element type.
+func ListValEmpty(elem Type) Value {
+	return Value{
+		ty: ListType(elem),
+		v:  []Value{},
+	}
+}
+
+// ObjectVal returns a Value of an object type whose attributes are the given
+// values. Unlike MapStringVal, every attribute keeps its own Value, so nested
+// objects and lists retain their full types.
+func ObjectVal(attrs map[string]Value) Value {
+	rawAttrs := make(map[string]Value, len(attrs))
+	attrTypes := make(map[string]Type, len(attrs))
+	for key, val := range attrs {
+		if err := val.GetError(); err != nil {
+			return Value{err: err}
+		}
+		rawAttrs[key] = val
+		attrTypes[key] = val.ty
+	}
+	return Value{
+		ty: ObjectType(attrTypes),
+		v:  rawAttrs,
+	}
+}
+
+// ObjectValWithType returns a Value of the given object type, such as one
+// made by ObjectTypeWithOptionalAttrs, whose attributes are the given values.
+// Each value must be of the type of its attribute or a null, and every
+// attribute that is not optional must be given.
+func ObjectValWithType(attrs map[string]Value, ty Type) Value {
+	if !ty.IsObjectType() {
+		return Value{err: fmt.Errorf("%s is not an object type", ty.FriendlyName())}
+	}
+	rawAttrs := make(map[string]Value, len(attrs))
+	for key, val := range attrs {
+		if err := val.GetError(); err != nil {
+			return Value{err: err}
+		}
+		if !ty.HasAttribute(key) {
+			return Value{err: fmt.Errorf("unexpected attribute %q", key)}
+		}
+		attrType := ty.AttributeType(key)
+		if val.IsNull() {
+			val = NullVal(attrType)
+		} else if !val.ty.Equals(attrType) {
+			return Value{err: fmt.Errorf("attribute %q must be of type %s", key, attrType.FriendlyName())}
+		}
+		rawAttrs[key] = val
+	}
+	for key := range ty.AttributeTypes() {
+		if _, ok := rawAttrs[key]; !ok && !ty.AttributeOptional(key) {
+			return Value{err: fmt.Errorf("attribute %q is required", key)}
+		}
+	}
+	return Value{
+		ty: ty,
+		v:  rawAttrs,
+	}
+}
@@ -3,9 +3,16 @@ package optional
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/big"
+	"mime"
 	"net"
+	"net/http"
 	"net/url"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -34,6 +41,16 @@ const (
 	FmtMustIsSQLObject     = "%s filed value must is sql "
 	FmtMustIsIp            = "%s filed value must is ip "
 	FmtMustIsNumber        = "%s filed value must is number type"
+	FmtMustList            = "%s filed value must is a list type"
+	FmtMustFile            = "%s filed value must is a file"
+	FmtMustMaxFileSize     = "%s filed file size must not exceed %d bytes"
+	FmtMustFileExt         = "%s filed file extension must in %v"
+	FmtMustFileMIME        = "%s filed file type must in %v"
+	FmtMustTime            = "%s filed value must is a time"
+	FmtMustIsJSON          = "%s filed value must is json"
+	FmtMustBase64          = "%s filed value must is base64"
+	FmtMustBefore          = "%s filed time must before %s"
+	FmtMustAfter           = "%s filed time must after %s"
 )
 
 func MustNotNil() Match {
@@ -44,17 +61,37 @@ func MustNotNil() Match {
 				if val.value.v.(string) != "" {
 					return nil
 				}
-			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-				if val.value.v.(int) != 0 {
+			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+				if !reflect.ValueOf(val.value.v).IsZero() {
 					return nil
 				}
 			case bool:
 				if val.value.v.(bool) {
 					return nil
 				}
+			case time.Time:
+				if !val.value.v.(time.Time).IsZero() {
+					return nil
+				}
+			case time.Duration:
+				if val.value.v.(time.Duration) != 0 {
+					return nil
+				}
+			case []byte:
+				if len(val.value.v.([]byte)) != 0 {
+					return nil
+				}
+			case *big.Float:
+				if val.value.v.(*big.Float).Sign() != 0 {
+					return nil
+				}
+			case *big.Int:
+				if val.value.v.(*big.Int).Sign() != 0 {
+					return nil
+				}
 			}
 		}
-		return fmt.Errorf(FmtMustNotNil, val.name)
+		return fmt.Errorf(FmtMustNotNil, val.field())
 	}
 }
 func MustString() Match {
@@ -62,7 +99,7 @@ func MustString() Match {
 		if val.value.ty == String {
 			return nil
 		}
-		return fmt.Errorf(FmtMustString, val.name)
+		return fmt.Errorf(FmtMustString, val.field())
 	}
 }
 func MustTrue() Match {
@@ -70,7 +107,7 @@ func MustTrue() Match {
 		if val.value.Equals(True) {
 			return nil
 		}
-		return errorf(FmtMustTrue, val.name)
+		return errorf(FmtMustTrue, val.field())
 	}
 }
 func MustHasSuffix(s string) Match {
@@ -79,12 +116,12 @@ func MustHasSuffix(s string) Match {
 			if strings.HasSuffix(val.value.v.(string), s) {
 				return nil
 			}
-			return errorf(FmtMustHasSuffix, val.name, s)
+			return errorf(FmtMustHasSuffix, val.field(), s)
 		}
 		if val.value.Equals(True) {
 			return nil
 		}
-		return errorf(FmtMustHasSuffix, val.name, s)
+		return errorf(FmtMustHasSuffix, val.field(), s)
 	}
 }
 func MustHasString(s string) Match {
@@ -93,12 +130,12 @@ func MustHasString(s string) Match {
 			if strings.Contains(val.value.v.(string), s) {
 				return nil
 			}
-			return errorf(FmtMustHasString, val.name, s)
+			return errorf(FmtMustHasString, val.field(), s)
 		}
 		if val.value.Equals(True) {
 			return nil
 		}
-		return errorf(FmtMustHasString, val.name, s)
+		return errorf(FmtMustHasString, val.field(), s)
 	}
 }
 func MustHasSymbol() Match {
@@ -106,7 +143,7 @@ func MustHasSymbol() Match {
 		if val.has(unicode.IsSymbol) {
 			return nil
 		}
-		return errorf(FmtMustHasSymbol, val.name)
+		return errorf(FmtMustHasSymbol, val.field())
 	}
 }
 func MustHasDigit() Match {
@@ -114,7 +151,7 @@ func MustHasDigit() Match {
 		if val.has(unicode.IsDigit) {
 			return nil
 		}
-		return errorf(FmtMustHasDigit, val.name)
+		return errorf(FmtMustHasDigit, val.field())
 	}
 }
 func MustHasLetter() Match {
@@ -122,7 +159,7 @@ func MustHasLetter() Match {
 		if val.has(unicode.IsLetter) {
 			return nil
 		}
-		return errorf(FmtMustHasLetter, val.name)
+		return errorf(FmtMustHasLetter, val.field())
 	}
 }
 func MustHasLower() Match {
@@ -130,7 +167,7 @@ func MustHasLower() Match {
 		if val.has(unicode.IsLower) {
 			return nil
 		}
-		return errorf(FmtMustHasLower, val.name)
+		return errorf(FmtMustHasLower, val.field())
 	}
 }
 func MustHasUpper() Match {
@@ -138,7 +175,7 @@ func MustHasUpper() Match {
 		if val.has(unicode.IsUpper) {
 			return nil
 		}
-		return errorf(FmtMustHasUpper, val.name)
+		return errorf(FmtMustHasUpper, val.field())
 	}
 }
 func MustIn(s []string) Match {
@@ -148,7 +185,7 @@ func MustIn(s []string) Match {
 				return nil
 			}
 		}
-		return errorf(FmtMustIn, val.name, s)
+		return errorf(FmtMustIn, val.field(), s)
 	}
 }
 func MustEquals(s string) Match {
@@ -156,28 +193,28 @@ func MustEquals(s string) Match {
 		if val.value.v.(string) == s {
 			return nil
 		}
-		return errorf(FmtMustEquals, val.name, s)
+		return errorf(FmtMustEquals, val.field(), s)
 	}
 
 }
 func MustIsLower() Match {
 	return func(val *validator) error {
-		return isStringFunc(val, unicode.IsLower, FmtMustIsLower, val.name)
+		return isStringFunc(val, unicode.IsLower, FmtMustIsLower, val.field())
 	}
 }
 func MustIsUpper() Match {
 	return func(val *validator) error {
-		return isStringFunc(val, unicode.IsUpper, FmtMustIsUpper, val.name)
+		return isStringFunc(val, unicode.IsUpper, FmtMustIsUpper, val.field())
 	}
 }
 func MustIsLetter() Match {
 	return func(val *validator) error {
-		return isStringFunc(val, unicode.IsLetter, FmtMustIsLetter, val.name)
+		return isStringFunc(val, unicode.IsLetter, FmtMustIsLetter, val.field())
 	}
 }
 func MustIsDigit() Match {
 	return func(val *validator) error {
-		return isStringFunc(val, unicode.IsDigit, FmtMustIsDigit, val.name)
+		return isStringFunc(val, unicode.IsDigit, FmtMustIsDigit, val.field())
 	}
 }
 func MustIsLowerOrDigit() Match {
@@ -187,7 +224,7 @@ func MustIsLowerOrDigit() Match {
 				return true
 			}
 			return false
-		}, FmtMustIsLowerOrDigit, val.name)
+		}, FmtMustIsLowerOrDigit, val.field())
 	}
 }
 func MustIsUpperOrDigit() Match {
@@ -197,7 +234,7 @@ func MustIsUpperOrDigit() Match {
 				return true
 			}
 			return false
-		}, FmtMustIsUpperOrDigit, val.name)
+		}, FmtMustIsUpperOrDigit, val.field())
 	}
 }
 func MustIsLetterOrDigit() Match {
@@ -207,7 +244,7 @@ func MustIsLetterOrDigit() Match {
 				return true
 			}
 			return false
-		}, FmtMustIsLetterOrDigit, val.name)
+		}, FmtMustIsLetterOrDigit, val.field())
 	}
 }
 func MustIsChinese() Match {
@@ -217,7 +254,7 @@ func MustIsChinese() Match {
 				return true
 			}
 			return false
-		}, FmtMustIsChinese, val.name)
+		}, FmtMustIsChinese, val.field())
 	}
 }
 func MustIsURL() Match {
@@ -242,7 +279,7 @@ func MustIsUUID() Match {
 		// urn:uuid:xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
 		case 36 + 9:
 			if strings.EqualFold(strings.ToLower(str[:9]), "urn:uuid:") {
-				return errorf(FmtMustIsUUID, val.name)
+				return errorf(FmtMustIsUUID, val.field())
 			}
 			str = str[9:]
 
@@ -256,17 +293,17 @@ func MustIsUUID() Match {
 			for i := range uuid {
 				uuid[i], ok = xtob(str[i*2], str[i*2+1])
 				if !ok {
-					return errorf(FmtMustIsUUID, val.name)
+					return errorf(FmtMustIsUUID, val.field())
 				}
 			}
 			return nil
 		default:
-			return errorf(FmtMustIsUUID, val.name)
+			return errorf(FmtMustIsUUID, val.field())
 		}
 		// s is now at least 36 bytes long
 		// it must be of the form  xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
 		if str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
-			return errorf(FmtMustIsUUID, val.name)
+			return errorf(FmtMustIsUUID, val.field())
 		}
 		for i, x := range [16]int{
 			0, 2, 4, 6,
@@ -276,7 +313,7 @@ func MustIsUUID() Match {
 			24, 26, 28, 30, 32, 34} {
 			v, ok := xtob(str[x], str[x+1])
 			if !ok {
-				return errorf(FmtMustIsUUID, val.name)
+				return errorf(FmtMustIsUUID, val.field())
 			}
 			uuid[i] = v
 		}
@@ -290,7 +327,7 @@ func MustIsSQLObject() Match {
 				return false
 			}
 			return true
-		}, FmtMustIsSQLObject, val.name)
+		}, FmtMustIsSQLObject, val.field())
 	}
 }
 func MustIsChinaMobile() Match {
@@ -301,10 +338,12 @@ func MustIsChinaMobile() Match {
 }
 func MustIsJSON() Match {
 	return func(val *validator) error {
-		var js json.RawMessage
-		if err := json.Unmarshal(val.value.v.([]byte), &js); err != nil {
-			//todo bytes and err
-			return err
+		b, err := val.value.Converter().Bytes()
+		if err != nil {
+			return errorf(FmtMustString, val.field())
+		}
+		if !json.Valid(b) {
+			return errorf(FmtMustIsJSON, val.field())
 		}
 		return nil
 	}
@@ -315,7 +354,7 @@ func MustIsIP() Match {
 			//todo ip的具体判断
 			return nil
 		}
-		return errorf(FmtMustIsIp, val.name)
+		return errorf(FmtMustIsIp, val.field())
 	}
 }
 func MustIsEmail() Match {
@@ -334,7 +373,98 @@ func MustIsNumberValue() Match {
 				return nil
 			}
 		}
-		return errorf(FmtMustIsNumber, val.name)
+		return errorf(FmtMustIsNumber, val.field())
+	}
+}
+
+// MustEach applies the given matches to every element of a list value.
+func MustEach(matches ...Match) Match {
+	return func(val *validator) error {
+		elems, err := val.value.Converter().List()
+		if err != nil {
+			return errorf(FmtMustList, val.field())
+		}
+		for i := range elems {
+			elem := validator{
+				matches: matches,
+				name:    fmt.Sprintf("%s[%d]", val.name, i),
+				source:  val.source,
+				value:   elems[i],
+			}
+			if err := elem.GetError(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MustMaxFileSize checks that an uploaded file is at most n bytes.
+func MustMaxFileSize(n int64) Match {
+	return func(val *validator) error {
+		fh, err := val.value.Converter().File()
+		if err != nil {
+			return errorf(FmtMustFile, val.field())
+		}
+		if fh.Size > n {
+			return errorf(FmtMustMaxFileSize, val.field(), n)
+		}
+		return nil
+	}
+}
+
+// MustFileExt checks the extension of an uploaded file name against the
+// given extensions, ignoring case. Extensions may be given with or without
+// the leading dot.
+func MustFileExt(exts ...string) Match {
+	return func(val *validator) error {
+		fh, err := val.value.Converter().File()
+		if err != nil {
+			return errorf(FmtMustFile, val.field())
+		}
+		ext := strings.TrimPrefix(filepath.Ext(fh.Filename), ".")
+		for i := range exts {
+			if strings.EqualFold(ext, strings.TrimPrefix(exts[i], ".")) {
+				return nil
+			}
+		}
+		return errorf(FmtMustFileExt, val.field(), exts)
+	}
+}
+
+// MustFileMIME checks the MIME type of an uploaded file, as sniffed from its
+// content with http.DetectContentType, against the given types. A type may
+// use a wildcard subtype such as image/*.
+func MustFileMIME(types ...string) Match {
+	return func(val *validator) error {
+		fh, err := val.value.Converter().File()
+		if err != nil {
+			return errorf(FmtMustFile, val.field())
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		head := make([]byte, 512)
+		n, err := io.ReadFull(f, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+		if err != nil {
+			return errorf(FmtMustFileMIME, val.field(), types)
+		}
+		for i := range types {
+			if strings.EqualFold(types[i], mediaType) {
+				return nil
+			}
+			if strings.HasSuffix(types[i], "/*") &&
+				strings.HasPrefix(mediaType, strings.ToLower(strings.TrimSuffix(types[i], "*"))) {
+				return nil
+			}
+		}
+		return errorf(FmtMustFileMIME, val.field(), types)
 	}
 }
 
@@ -347,3 +477,33 @@ func isStringFunc(val *validator, fn func(r rune) bool, msg string, a ...interfa
 func errorf(msg string, a ...interface{}) error {
 	return fmt.Errorf(msg, a...)
 }
+
+// MustBefore checks that a time, or a string holding one in one of the
+// DefaultTimeLayouts, is before t.
+func MustBefore(t time.Time) Match {
+	return func(val *validator) error {
+		v, err := val.value.Converter().Time()
+		if err != nil {
+			return errorf(FmtMustTime, val.field())
+		}
+		if v.Before(t) {
+			return nil
+		}
+		return errorf(FmtMustBefore, val.field(), t.Format(time.RFC3339))
+	}
+}
+
+// MustAfter checks that a time, or a string holding one in one of the
+// DefaultTimeLayouts, is after t.
+func MustAfter(t time.Time) Match {
+	return func(val *validator) error {
+		v, err := val.value.Converter().Time()
+		if err != nil {
+			return errorf(FmtMustTime, val.field())
+		}
+		if v.After(t) {
+			return nil
+		}
+		return errorf(FmtMustAfter, val.field(), t.Format(time.RFC3339))
+	}
+}
@@ -7,10 +7,17 @@ import (
 type validator struct {
 	matches []Match
 	name    string // 字段名
+	source  string // 字段来源
 	value   Value
 	strict  bool // 严格模式
 }
 
+// field returns the field name as shown in error messages, prefixed with
+// the source it came from when known, such as query parameter `page`.
+func (o validator) field() string {
+	return fieldName(o.source, o.name)
+}
+
 func (o validator) is(fn func(r rune) bool) bool {
 	for _, v := range o.value.v.(string) {
 		if !fn(v) {
@@ -53,6 +60,14 @@ func (o validator) Align(a interface{}) error {
 	}
 	return o.value.UnMarshal(a)
 }
+
+// Optional marks the validated field as optional, so Validates skips it
+// instead of failing when the field is absent.
+func (o validator) Optional() validator {
+	o.strict = false
+	return o
+}
+
 func (o validator) Processor(name string, apply ...Apply) processor {
 	return o.value.Processor(name, apply...)
 }
@@ -123,5 +138,6 @@ func Validate(name string, matches ...Match) validator {
 	return validator{
 		name:    name,
 		matches: matches,
+		strict:  true,
 	}
 }
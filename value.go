@@ -1,10 +1,16 @@
 package optional
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"mime/multipart"
 	"net/http"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
 type Belief interface {
@@ -13,14 +19,12 @@ type Belief interface {
 	Value() Value
 }
 
-const (
-	strict = true
-)
-
 type Value struct {
-	err error
-	ty  Type
-	v   interface{}
+	err     error
+	ty      Type
+	v       interface{}
+	src     string     // source of the value, such as query parameter
+	origins *originSet // source of each key of a merged value
 }
 
 func (val Value) Type() Type {
@@ -38,7 +42,7 @@ func (val Value) Converter() converter {
 }
 
 func (val Value) Equals(v Value) bool {
-	if val.ty.Equals(v.ty) && val.v == val.v {
+	if val.ty.Equals(v.ty) && reflect.DeepEqual(val.v, v.v) {
 		return true
 	}
 	return false
@@ -60,6 +64,18 @@ func (val Value) IsMapValue() bool {
 	return val.ty.IsMapType()
 }
 
+func (val Value) IsObjectValue() bool {
+	return val.ty.IsObjectType()
+}
+
+func (val Value) IsFileValue() bool {
+	return val.ty.IsFileType()
+}
+
+func (val Value) IsListValue() bool {
+	return val.ty.IsListType()
+}
+
 var NilVal = Value{
 	ty: Type{typeImpl: nil},
 	v:  nil,
@@ -70,8 +86,44 @@ func (val Value) UnMarshal(v interface{}) error {
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return &InvalidUnmarshalError{reflect.TypeOf(v)}
 	}
+	if val.IsNull() {
+		// nothing to assign, the variable keeps its current value
+		return nil
+	}
+	rve := rv.Elem()
+	if val.IsFileValue() && rve.Kind() != reflect.Slice {
+		return val.unMarshalFile(rve)
+	}
+	switch rve.Kind() {
+	case reflect.Ptr:
+		if rve.IsNil() {
+			rve.Set(reflect.New(rve.Type().Elem()))
+		}
+		return val.UnMarshal(rve.Interface())
+	case reflect.Interface:
+		if rve.NumMethod() == 0 {
+			rve.Set(reflect.ValueOf(val.goValue()))
+			return nil
+		}
+	}
+	if val.IsPrimitiveValue() && rve.Type() == bytesType {
+		b, err := val.Converter().Bytes()
+		if err != nil {
+			return err
+		}
+		rve.Set(reflect.ValueOf(append([]byte(nil), b...)))
+		return nil
+	}
+	if (val.IsPrimitiveValue() || val.IsFileValue()) && rve.Kind() == reflect.Slice {
+		// a key sent once still aligns into a slice
+		return ListVal([]Value{val}).unMarshalList(rve)
+	}
 	if val.IsPrimitiveValue() {
-		rve := rv.Elem()
+		// time.Duration is an int64, time.Time and the big numbers are
+		// structs, check them first
+		if ok, err := val.unMarshalStd(rve); ok {
+			return err
+		}
 		switch rve.Type().Kind() {
 		case reflect.String:
 			cv, err := val.Converter().String()
@@ -140,17 +192,234 @@ func (val Value) UnMarshal(v interface{}) error {
 				return err
 			}
 			rve.Set(reflect.ValueOf(cv))
-		case reflect.Ptr:
-			v = &val.v
+		case reflect.Float32:
+			cv, err := val.Converter().Float32()
+			if err != nil {
+				return err
+			}
+			rve.Set(reflect.ValueOf(cv))
+		case reflect.Float64:
+			cv, err := val.Converter().Float64()
+			if err != nil {
+				return err
+			}
+			rve.Set(reflect.ValueOf(cv))
+		case reflect.Bool:
+			cv, err := val.Converter().Bool()
+			if err != nil {
+				return err
+			}
+			rve.Set(reflect.ValueOf(cv))
 		}
 		return nil
 	}
-	if val.IsMapValue() {
-		//todo
+	if val.IsListValue() {
+		return val.unMarshalList(rv.Elem())
+	}
+	if val.IsMapValue() || val.IsObjectValue() {
+		return val.unMarshalAttrs(rve)
+	}
+	return nil
+}
+
+func (val Value) unMarshalList(rve reflect.Value) error {
+	elems, ok := val.v.([]Value)
+	if !ok {
+		return ConvertError
+	}
+	switch rve.Kind() {
+	case reflect.Slice:
+		s := reflect.MakeSlice(rve.Type(), len(elems), len(elems))
+		for i := range elems {
+			if err := elems[i].UnMarshal(s.Index(i).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		rve.Set(s)
+		return nil
+	case reflect.Array:
+		if rve.Len() != len(elems) {
+			return fmt.Errorf("list of %d elements can not unmarshal into %s", len(elems), rve.Type())
+		}
+		for i := range elems {
+			if err := elems[i].UnMarshal(rve.Index(i).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return ConvertError
+	}
+}
+
+var fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+// unMarshalStd aligns a primitive value into a time.Time, time.Duration,
+// big.Float, big.Int or big.Rat, it returns false for any other variable.
+func (val Value) unMarshalStd(rve reflect.Value) (bool, error) {
+	switch rve.Type() {
+	case bigFloatType:
+		f, err := val.Converter().BigFloat()
+		if err != nil {
+			return true, err
+		}
+		rve.Addr().Interface().(*big.Float).Set(f)
+		return true, nil
+	case bigIntType:
+		i, err := val.Converter().BigInt()
+		if err != nil {
+			return true, err
+		}
+		rve.Addr().Interface().(*big.Int).Set(i)
+		return true, nil
+	case bigRatType:
+		r, err := val.Converter().BigRat()
+		if err != nil {
+			return true, err
+		}
+		rve.Addr().Interface().(*big.Rat).Set(r)
+		return true, nil
+	case timeType:
+		t, err := val.Converter().Time()
+		if err != nil {
+			return true, err
+		}
+		rve.Set(reflect.ValueOf(t))
+		return true, nil
+	case durationType:
+		d, err := val.Converter().Duration()
+		if err != nil {
+			return true, err
+		}
+		rve.Set(reflect.ValueOf(d))
+		return true, nil
+	}
+	return false, nil
+}
+
+// unMarshalFile assigns a file value to a multipart.FileHeader or a pointer
+// to one.
+func (val Value) unMarshalFile(rve reflect.Value) error {
+	fh, err := val.Converter().File()
+	if err != nil {
+		return err
+	}
+	switch {
+	case rve.Type() == fileHeaderType:
+		rve.Set(reflect.ValueOf(*fh))
+	case rve.Type() == reflect.PtrTo(fileHeaderType):
+		rve.Set(reflect.ValueOf(fh))
+	case rve.Kind() == reflect.Interface && rve.NumMethod() == 0:
+		rve.Set(reflect.ValueOf(fh))
+	default:
+		return ConvertError
 	}
 	return nil
 }
 
+// unMarshalAttrs assigns the attributes of a map or object value to a struct
+// or to a map with string keys.
+func (val Value) unMarshalAttrs(rve reflect.Value) error {
+	switch rve.Kind() {
+	case reflect.Struct:
+		names := val.attrNames()
+		rt := rve.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name, ok := matchFieldName(field, names)
+			if !ok {
+				continue
+			}
+			if err := val.GetMapValue(name).UnMarshal(rve.Field(i).Addr().Interface()); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	case reflect.Map:
+		if rve.Type().Key().Kind() != reflect.String {
+			return ConvertError
+		}
+		if rve.IsNil() {
+			rve.Set(reflect.MakeMap(rve.Type()))
+		}
+		for _, name := range val.attrNames() {
+			ev := reflect.New(rve.Type().Elem())
+			if err := val.GetMapValue(name).UnMarshal(ev.Interface()); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			rve.SetMapIndex(reflect.ValueOf(name).Convert(rve.Type().Key()), ev.Elem())
+		}
+		return nil
+	default:
+		return ConvertError
+	}
+}
+
+// attrNames returns the keys of a map value or the attribute names of an
+// object value.
+func (val Value) attrNames() []string {
+	var names []string
+	switch m := val.v.(type) {
+	case map[string]interface{}:
+		names = make([]string, 0, len(m))
+		for k := range m {
+			names = append(names, k)
+		}
+	case map[string]Value:
+		names = make([]string, 0, len(m))
+		for k := range m {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchFieldName finds the key a struct field is aligned from. The json tag
+// name is used when present, otherwise the field name is matched exactly,
+// then case insensitively and finally in snake case.
+func matchFieldName(field reflect.StructField, names []string) (string, bool) {
+	want := field.Name
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		tag = strings.Split(tag, ",")[0]
+		if tag == "-" {
+			return "", false
+		}
+		if tag != "" {
+			want = tag
+		}
+	}
+	for _, name := range names {
+		if name == want {
+			return name, true
+		}
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, want) {
+			return name, true
+		}
+	}
+	snake := ToSnakeCase(want)
+	for _, name := range names {
+		if name == snake {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 func (val Value) StringProcessor() stringProcessor {
 	return &linkProcessor{
 		v: val,
@@ -158,11 +427,11 @@ func (val Value) StringProcessor() stringProcessor {
 }
 
 func (val Value) Validate(name string, match ...Match) validator {
-	return validator{matches: match, name: name, value: val}
+	return validator{matches: match, name: name, value: val, strict: true}
 }
 
 func (val Value) Validates(validates ...validator) validators {
-	if val.IsMapValue() && val.ty.Len() == 0 {
+	if (val.IsMapValue() || val.IsObjectValue()) && val.ty.Len() == 0 {
 		val.err = errorf("map value  is null!!!")
 		return validators{
 			value: val,
@@ -172,13 +441,14 @@ func (val Value) Validates(validates ...validator) validators {
 	for i := range validates {
 		value := val.GetMapValue(validates[i].name)
 		if value.IsNull() {
-			if strict {
+			if validates[i].strict && !val.ty.AttributeOptional(validates[i].name) {
 				val.err = errorf("no have [%s]  field to validate", validates[i].name)
 				return validators{value: val}
 			}
 			continue
 		}
 		validates[i].value = value
+		validates[i].source = val.Origin(validates[i].name)
 		m[validates[i].name] = validates[i]
 	}
 	return validators{
@@ -192,13 +462,14 @@ func (val Value) Processors(ps ...processor) processors {
 	for i := range ps {
 		value := val.GetMapValue(ps[i].name)
 		if value.IsNull() {
-			if strict {
+			if !val.ty.AttributeOptional(ps[i].name) {
 				val.err = errorf("no have [%s]  field to process", ps[i].name)
 				return processors{value: val}
 			}
 			continue
 		}
 		ps[i].value = value
+		ps[i].source = val.Origin(ps[i].name)
 		m[ps[i].name] = ps[i]
 	}
 	return processors{
@@ -211,7 +482,7 @@ func (val Value) Processor(name string, apply ...Apply) processor {
 	if err := val.GetError(); err != nil {
 		return processor{value: val, name: name}
 	}
-	if val.IsMapValue() {
+	if val.IsMapValue() || val.IsObjectValue() {
 		val.err = errors.New("not support map value processor")
 		return processor{value: val, name: name}
 	}
@@ -227,6 +498,10 @@ func (val Value) GetMapValue(name string) Value {
 	if val.IsMapValue() {
 		v, ok := val.v.(map[string]interface{})
 		if ok {
+			if _, has := v[name]; !has && val.src == sourceHeader {
+				// header names are case-insensitive, the keys are canonical
+				name = http.CanonicalHeaderKey(name)
+			}
 			tt, ok2 := val.ty.typeImpl.(typeStringMap)
 			if ok2 {
 				return Value{
@@ -236,6 +511,18 @@ func (val Value) GetMapValue(name string) Value {
 			}
 		}
 	}
+	if val.IsObjectValue() {
+		attrs, ok := val.v.(map[string]Value)
+		if ok {
+			if attr, has := attrs[name]; has {
+				return attr
+			}
+			if val.ty.HasAttribute(name) {
+				return NullVal(val.ty.AttributeType(name))
+			}
+			return Value{}
+		}
+	}
 
 	// todo
 	return Value{
@@ -243,6 +530,19 @@ func (val Value) GetMapValue(name string) Value {
 	}
 }
 
+// GetListValue returns the element at the given index of a list value.
+func (val Value) GetListValue(i int) Value {
+	if val.IsListValue() {
+		elems, ok := val.v.([]Value)
+		if ok && i >= 0 && i < len(elems) {
+			return elems[i]
+		}
+	}
+	return Value{
+		err: errorf("index %d no have value", i),
+	}
+}
+
 func (val Value) SetMapValue(name string, value Value) Value {
 	if val.IsMapValue() {
 		v, ok := val.v.(map[string]interface{})
@@ -255,6 +555,22 @@ func (val Value) SetMapValue(name string, value Value) Value {
 			}
 		}
 	}
+	if val.IsObjectValue() {
+		attrs, ok := val.v.(map[string]Value)
+		if ok {
+			attrs[name] = value
+			// the type may be a schema shared with other values, such as
+			// the type given to ObjectValWithType, so it is copied
+			to := val.ty.typeImpl.(typeObject)
+			attrTypes := make(map[string]Type, len(to.AttrTypes)+1)
+			for k := range to.AttrTypes {
+				attrTypes[k] = to.AttrTypes[k]
+			}
+			attrTypes[name] = value.ty
+			val.ty = Type{typeObject{AttrTypes: attrTypes, AttrOptional: to.AttrOptional}}
+			return val
+		}
+	}
 	// todo
 	return val
 }
@@ -264,7 +580,7 @@ func (val Value) Aligns(aligns ...align) error {
 	if err := val.GetError(); err != nil {
 		return err
 	}
-	if !val.IsMapValue() {
+	if !val.IsMapValue() && !val.IsObjectValue() {
 		return errors.New("single value is not support aligns")
 	}
 	for i := range aligns {
@@ -285,9 +601,17 @@ func (val Value) Align(a align) error {
 	return nil
 }
 
+// GetErrorResponseWriter writes the error of the value to resp. The status
+// code is 400 Bad Request unless the error provides its own with a
+// StatusCode() int method, as *UnsupportedMediaTypeError does.
 func (val Value) GetErrorResponseWriter(resp http.ResponseWriter) error {
 	if val.err != nil {
-		resp.WriteHeader(http.StatusBadRequest)
+		status := http.StatusBadRequest
+		var sc interface{ StatusCode() int }
+		if errors.As(val.err, &sc) {
+			status = sc.StatusCode()
+		}
+		resp.WriteHeader(status)
 		resp.Write([]byte(val.err.Error()))
 		return val.err
 	}
@@ -295,7 +619,58 @@ func (val Value) GetErrorResponseWriter(resp http.ResponseWriter) error {
 }
 
 func (val Value) String() string {
-	return fmt.Sprint(val.v)
+	return fmt.Sprint(val.goValue())
+}
+
+// goValue returns the plain go representation of the value, with any nested
+// list elements and object attributes unwrapped, so it can be handed to fmt or encoding/json.
+func (val Value) goValue() interface{} {
+	if val.IsListValue() {
+		elems, ok := val.v.([]Value)
+		if !ok {
+			return nil
+		}
+		l := make([]interface{}, len(elems))
+		for i := range elems {
+			l[i] = elems[i].goValue()
+		}
+		return l
+	}
+	if val.IsMapValue() {
+		m, ok := val.v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		tm := val.ty.typeImpl.(typeStringMap)
+		res := make(map[string]interface{}, len(m))
+		for k := range m {
+			res[k] = Value{ty: tm.GetStringMapType(k), v: m[k]}.goValue()
+		}
+		return res
+	}
+	if val.IsObjectValue() {
+		attrs, ok := val.v.(map[string]Value)
+		if !ok {
+			return nil
+		}
+		res := make(map[string]interface{}, len(attrs))
+		for k := range attrs {
+			res[k] = attrs[k].goValue()
+		}
+		return res
+	}
+	switch v := val.v.(type) {
+	case *big.Float:
+		if !v.IsInf() {
+			// keeps every digit when encoded as json
+			return json.Number(v.Text('g', -1))
+		}
+	case *big.Int:
+		if val.ty.IsDecimalType() {
+			return json.Number(decimalString(v, val.ty.DecimalScale()))
+		}
+	}
+	return val.v
 }
 
 func (val Value) isNumber() bool {
@@ -324,8 +699,10 @@ func (val Value) isNumber() bool {
 		return true
 	case Float32:
 		return true
+	case BigNumber:
+		return true
 	default:
-		return false
+		return val.ty.IsDecimalType()
 	}
 }
 func (val Value) isString() bool {
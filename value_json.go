@@ -0,0 +1,465 @@
+package optional
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// JSONOption configures the JSON encoding of values.
+type JSONOption func(*jsonOptions)
+
+type jsonOptions struct {
+	int64String bool
+	// typed writes values where the type is DynamicPseudoType along with
+	// their type, see SimpleJSONValue
+	typed bool
+}
+
+// WithJSONInt64String emits Int64 and Uint64 values as JSON strings, such as
+// "9007199254740993", since JavaScript can not read them exactly as numbers.
+// UnmarshalJSON reads either form.
+func WithJSONInt64String() JSONOption {
+	return func(o *jsonOptions) {
+		o.int64String = true
+	}
+}
+
+// MarshalJSON implements json.Marshaler, see converter.JSON.
+func (val Value) MarshalJSON() ([]byte, error) {
+	return val.Converter().JSON()
+}
+
+// JSON encodes the value as JSON guided by its type. Null is null, times
+// are RFC 3339 strings, durations strings such as "1h30m", bytes standard
+// base64 strings and big numbers and decimals numbers that keep every digit.
+// The JSON does not carry the type, use UnmarshalJSON with the same type to
+// read it back, or SimpleJSONValue to keep the type along.
+func (c converter) JSON(opts ...JSONOption) ([]byte, error) {
+	o := &jsonOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var buf bytes.Buffer
+	if err := marshalJSONVal(&buf, c.value, o); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalJSONVal(buf *bytes.Buffer, val Value, o *jsonOptions) error {
+	if err := val.GetError(); err != nil {
+		return err
+	}
+	if val.IsNull() {
+		buf.WriteString("null")
+		return nil
+	}
+	switch {
+	case val.ty.IsListType():
+		elems, _ := val.v.([]Value)
+		buf.WriteByte('[')
+		for i := range elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := marshalJSONAt(buf, elems[i], val.ty.ElementType(), o); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case val.ty.IsMapType(), val.ty.IsObjectType():
+		names := val.attrNames()
+		sort.Strings(names)
+		buf.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(name)
+			buf.Write(key)
+			buf.WriteByte(':')
+			var attrType Type
+			if val.ty.IsObjectType() {
+				attrType = val.ty.AttributeType(name)
+			} else {
+				attrType = val.ty.typeImpl.(typeStringMap).GetStringMapType(name)
+			}
+			if err := marshalJSONAt(buf, val.GetMapValue(name), attrType, o); err != nil {
+				return fmt.Errorf("attribute %q: %w", name, err)
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+
+	var v interface{}
+	switch x := val.v.(type) {
+	case time.Duration:
+		v = x.String()
+	case int64:
+		if o.int64String {
+			v = strconv.FormatInt(x, 10)
+		} else {
+			v = x
+		}
+	case uint64:
+		if o.int64String {
+			v = strconv.FormatUint(x, 10)
+		} else {
+			v = x
+		}
+	case float32:
+		if math.IsInf(float64(x), 0) || math.IsNaN(float64(x)) {
+			return fmt.Errorf("unsupported json number %v", x)
+		}
+		v = x
+	case float64:
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			return fmt.Errorf("unsupported json number %v", x)
+		}
+		v = x
+	case *big.Float:
+		if x.IsInf() {
+			// infinities have no json number, ParseBigNumberVal reads them back
+			v = x.String()
+		} else {
+			v = json.Number(x.Text('g', -1))
+		}
+	case *big.Int:
+		if !val.ty.IsDecimalType() {
+			return ConvertError
+		}
+		v = json.Number(decimalString(x, val.ty.DecimalScale()))
+	case bool, string, int, int8, int16, int32, uint, uint8, uint16, uint32, time.Time, []byte:
+		// encoding/json writes times as RFC 3339 and bytes as base64
+		v = x
+	default:
+		return fmt.Errorf("unsupported json value of type %s", val.ty.FriendlyName())
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// marshalJSONAt writes val found where the type is ty, such as an element of
+// a list of ty. With the typed option a value where the type is unknown or
+// DynamicPseudoType is written along with its own type as a two element
+// array of the type and the value, as by MarshalMsgpack.
+func marshalJSONAt(buf *bytes.Buffer, val Value, ty Type, o *jsonOptions) error {
+	if !o.typed || (ty.typeImpl != nil && !ty.IsDynamicType()) ||
+		val.ty.typeImpl == nil || val.ty.IsDynamicType() {
+		return marshalJSONVal(buf, val, o)
+	}
+	tyJSON, err := json.Marshal(val.ty)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('[')
+	buf.Write(tyJSON)
+	buf.WriteByte(',')
+	if err := marshalJSONVal(buf, val, o); err != nil {
+		return err
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// UnmarshalJSON decodes JSON written by converter.JSON into a value of the
+// given type. Numbers may also be given as strings and durations as a
+// number of nanoseconds. An attribute missing from an object is an error
+// unless it is optional, JSON null is a null of the type. With
+// DynamicPseudoType the value is decoded as by DecodeJSONVal.
+func UnmarshalJSON(data []byte, ty Type) (Value, error) {
+	if ty.typeImpl == nil {
+		return NilVal, errors.New("can not unmarshal into an invalid type")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return NilVal, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return NilVal, errors.New("invalid data after top-level json value")
+	}
+	return unmarshalJSONVal(raw, ty, false)
+}
+
+// unmarshalJSONVal decodes raw into a value of the given type. With typed a
+// value where the type is DynamicPseudoType is read along with its own type,
+// as written by marshalJSONAt.
+func unmarshalJSONVal(raw interface{}, ty Type, typed bool) (Value, error) {
+	if raw == nil {
+		return NullVal(ty), nil
+	}
+	switch {
+	case ty.IsDynamicType() && typed:
+		pair, ok := raw.([]interface{})
+		if !ok || len(pair) != 2 {
+			return NilVal, errors.New("a dynamic value must be an array of the type and the value")
+		}
+		tyJSON, err := json.Marshal(pair[0])
+		if err != nil {
+			return NilVal, err
+		}
+		var valType Type
+		if err := json.Unmarshal(tyJSON, &valType); err != nil {
+			return NilVal, err
+		}
+		if valType.IsDynamicType() {
+			return NilVal, errors.New("a dynamic value must have a known type")
+		}
+		return unmarshalJSONVal(pair[1], valType, typed)
+	case ty.IsDynamicType():
+		return jsonVal(raw)
+	case ty.IsListType():
+		elems, ok := raw.([]interface{})
+		if !ok {
+			return NilVal, jsonTypeError(raw, ty)
+		}
+		res := make([]Value, len(elems))
+		for i := range elems {
+			var err error
+			if res[i], err = unmarshalJSONVal(elems[i], ty.ElementType(), typed); err != nil {
+				return NilVal, fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return Value{ty: ty, v: res}, nil
+	case ty.IsMapType():
+		attrs, ok := raw.(map[string]interface{})
+		if !ok {
+			return NilVal, jsonTypeError(raw, ty)
+		}
+		impl := ty.typeImpl.(typeStringMap)
+		rawMap := make(map[string]interface{}, len(attrs))
+		types := make(map[string]Type, len(attrs))
+		for name := range attrs {
+			attrType := impl.GetStringMapType(name)
+			if attrType.typeImpl == nil {
+				attrType = DynamicPseudoType
+			}
+			attr, err := unmarshalJSONVal(attrs[name], attrType, typed)
+			if err != nil {
+				return NilVal, fmt.Errorf("attribute %q: %w", name, err)
+			}
+			rawMap[name] = attr.v
+			types[name] = attr.ty
+		}
+		return Value{ty: Type{typeStringMap{AttrType: types, ElemType: impl.ElemType}}, v: rawMap}, nil
+	case ty.IsObjectType():
+		attrs, ok := raw.(map[string]interface{})
+		if !ok {
+			return NilVal, jsonTypeError(raw, ty)
+		}
+		res := make(map[string]Value, len(attrs))
+		for name := range attrs {
+			if !ty.HasAttribute(name) {
+				return NilVal, fmt.Errorf("unexpected attribute %q", name)
+			}
+			attr, err := unmarshalJSONVal(attrs[name], ty.AttributeType(name), typed)
+			if err != nil {
+				return NilVal, fmt.Errorf("attribute %q: %w", name, err)
+			}
+			res[name] = attr
+		}
+		for name := range ty.AttributeTypes() {
+			if _, ok := res[name]; !ok && !ty.AttributeOptional(name) {
+				return NilVal, fmt.Errorf("attribute %q is required", name)
+			}
+		}
+		return Value{ty: ty, v: res}, nil
+	}
+	return unmarshalJSONPrimitive(raw, ty)
+}
+
+func unmarshalJSONPrimitive(raw interface{}, ty Type) (Value, error) {
+	if ty == Bool {
+		if b, ok := raw.(bool); ok {
+			return BoolVal(b), nil
+		}
+		return NilVal, jsonTypeError(raw, ty)
+	}
+	var s string
+	switch v := raw.(type) {
+	case string:
+		s = v
+	case json.Number:
+		if ty == String || ty == Time || ty == Bytes {
+			return NilVal, jsonTypeError(raw, ty)
+		}
+		if ty == Duration {
+			n, err := v.Int64()
+			if err != nil {
+				return NilVal, fmt.Errorf("a duration is required")
+			}
+			return DurationVal(time.Duration(n)), nil
+		}
+		s = string(v)
+	default:
+		return NilVal, jsonTypeError(raw, ty)
+	}
+
+	switch ty {
+	case String:
+		return StringVal(s), nil
+	case Time:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return NilVal, fmt.Errorf("a RFC 3339 time is required")
+		}
+		return TimeVal(t), nil
+	case Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return NilVal, fmt.Errorf("a duration is required")
+		}
+		return DurationVal(d), nil
+	case Bytes:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return NilVal, fmt.Errorf("a base64 string is required")
+		}
+		return BytesVal(b), nil
+	case BigNumber:
+		return ParseBigNumberVal(s)
+	case Float32:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return NilVal, fmt.Errorf("a number is required")
+		}
+		return Float32Val(float32(f)), nil
+	case Float64:
+		return ParseFloat64Val(s)
+	}
+	if ty.IsDecimalType() {
+		return ParseDecimalVal(s, ty.DecimalScale(), big.ToNearestEven)
+	}
+	return parseIntegerVal(s, ty)
+}
+
+// parseIntegerVal parses s as an integer of the given type, out of range
+// numbers are an error.
+func parseIntegerVal(s string, ty Type) (Value, error) {
+	var bits int
+	signed := true
+	switch ty {
+	case Int:
+		bits = strconv.IntSize
+	case Int8:
+		bits = 8
+	case Int16:
+		bits = 16
+	case Int32:
+		bits = 32
+	case Int64:
+		bits = 64
+	case Uint:
+		bits, signed = strconv.IntSize, false
+	case Uint8:
+		bits, signed = 8, false
+	case Uint16:
+		bits, signed = 16, false
+	case Uint32:
+		bits, signed = 32, false
+	case Uint64:
+		bits, signed = 64, false
+	default:
+		return NilVal, fmt.Errorf("unsupported json value of type %s", ty.FriendlyName())
+	}
+	if signed {
+		i, err := strconv.ParseInt(s, 10, bits)
+		if err != nil {
+			return NilVal, fmt.Errorf("%s is required", ty.FriendlyName())
+		}
+		return Int64Val(i).Converter().To(ty)
+	}
+	u, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return NilVal, fmt.Errorf("%s is required", ty.FriendlyName())
+	}
+	return Uint64Val(u).Converter().To(ty)
+}
+
+func jsonTypeError(raw interface{}, ty Type) error {
+	var got string
+	switch raw.(type) {
+	case bool:
+		got = "bool"
+	case string:
+		got = "string"
+	case json.Number:
+		got = "number"
+	case []interface{}:
+		got = "array"
+	default:
+		got = "object"
+	}
+	return fmt.Errorf("json %s can not be a value of type %s", got, ty.FriendlyName())
+}
+
+// SimpleJSONValue wraps a Value so that its JSON carries the type along,
+// as {"value": ..., "type": ...}, and decodes without knowing the type
+// beforehand. This keeps values intact through caches and queues. Values
+// where the type is DynamicPseudoType, such as the elements of a list of
+// DynamicPseudoType, are written along with their own type as a two element
+// array of the type and the value.
+type SimpleJSONValue struct {
+	Value
+	// Int64String writes Int64 and Uint64 values as JSON strings, see
+	// WithJSONInt64String.
+	Int64String bool
+}
+
+type simpleJSONValue struct {
+	Value json.RawMessage `json:"value"`
+	Type  Type            `json:"type"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v SimpleJSONValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	o := &jsonOptions{int64String: v.Int64String, typed: true}
+	if err := marshalJSONVal(&buf, v.Value, o); err != nil {
+		return nil, err
+	}
+	return json.Marshal(simpleJSONValue{Value: buf.Bytes(), Type: v.Value.ty})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *SimpleJSONValue) UnmarshalJSON(data []byte) error {
+	var s simpleJSONValue
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if len(s.Value) == 0 {
+		return errors.New("a value is required")
+	}
+	if s.Type.typeImpl == nil {
+		return errors.New("can not unmarshal into an invalid type")
+	}
+	dec := json.NewDecoder(bytes.NewReader(s.Value))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	val, err := unmarshalJSONVal(raw, s.Type, true)
+	if err != nil {
+		return err
+	}
+	v.Value = val
+	return nil
+}
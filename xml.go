@@ -0,0 +1,159 @@
+package optional
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// xmlAttrPrefix is put in front of the name of an XML attribute to tell it
+// apart from a child element of the same name.
+const xmlAttrPrefix = "@"
+
+// xmlTextKey holds the text of an element that also has attributes or child
+// elements.
+const xmlTextKey = "#text"
+
+// DecodeXMLVal reads an XML document from r and returns the content of its
+// root element as a Value, the name of the root element itself is dropped.
+//
+// An element holding only text becomes a String value. An element with
+// attributes or child elements becomes an object value: each child element
+// is an attribute named after the element, a child element that appears
+// more than once becomes a list, of DynamicPseudoType if some of them hold
+// text and others child elements, each XML attribute is an attribute named
+// after it with a leading "@", and any text is kept under "#text".
+//
+// The limit options, such as WithMaxBytes, are applied to the document as it
+// is read, decoding stops at the first element exceeding one of them.
+func DecodeXMLVal(r io.Reader, opts ...SourceOption) Value {
+	o := newSourceOptions(opts)
+	dec := xml.NewDecoder(o.limitReader(r))
+	var stack []*xmlNode
+	keys := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return Value{err: errors.New("没有期望的请求参数和值")}
+		}
+		if err != nil {
+			return Value{err: limitError(err)}
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// the parent of the element, or the element itself if it has
+			// attributes, becomes an object as deep as it is in the stack
+			depth := len(stack)
+			if len(t.Attr) > 0 {
+				depth++
+			}
+			if o.maxDepth > 0 && depth > o.maxDepth {
+				return Value{err: &LimitError{Limit: LimitDepth, Max: int64(o.maxDepth)}}
+			}
+			node := &xmlNode{}
+			for _, attr := range t.Attr {
+				if err := o.addXMLChild(node, xmlAttrPrefix+attr.Name.Local, StringVal(attr.Value), &keys); err != nil {
+					return Value{err: err}
+				}
+			}
+			stack = append(stack, node)
+		case xml.CharData:
+			if len(stack) > 0 {
+				text := &stack[len(stack)-1].text
+				text.Write(t)
+				if max := o.maxStringLength; max > 0 && text.Len() > max && len(strings.TrimSpace(text.String())) > max {
+					return Value{err: &LimitError{Limit: LimitStringLength, Max: int64(max)}}
+				}
+			}
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			val, err := node.value()
+			if err != nil {
+				return Value{err: err}
+			}
+			if len(stack) == 0 {
+				return o.limit(val)
+			}
+			if err := o.addXMLChild(stack[len(stack)-1], t.Name.Local, val, &keys); err != nil {
+				return Value{err: err}
+			}
+		}
+	}
+}
+
+// HttpRequestXMLVal decodes the XML request body into a Value, see
+// DecodeXMLVal for how XML documents are mapped onto types.
+func HttpRequestXMLVal(req *http.Request, opts ...SourceOption) Value {
+	if req.Body == nil {
+		return Value{err: errors.New("没有期望的请求参数和值")}
+	}
+	return DecodeXMLVal(req.Body, opts...).withSource(sourceBody)
+}
+
+// xmlNode collects the content of an element while it is decoded.
+type xmlNode struct {
+	names    []string
+	children map[string][]Value
+	text     strings.Builder
+}
+
+func (n *xmlNode) add(name string, val Value) {
+	if n.children == nil {
+		n.children = map[string][]Value{}
+	}
+	if _, ok := n.children[name]; !ok {
+		n.names = append(n.names, name)
+	}
+	n.children[name] = append(n.children[name], val)
+}
+
+// addXMLChild adds a child element or an attribute to n, checking the key,
+// array length and string length limits. keys counts the keys of the whole
+// document.
+func (o sourceOptions) addXMLChild(n *xmlNode, name string, val Value, keys *int) error {
+	if s, ok := val.v.(string); ok && o.maxStringLength > 0 && len(s) > o.maxStringLength {
+		return &LimitError{Limit: LimitStringLength, Max: int64(o.maxStringLength)}
+	}
+	if _, ok := n.children[name]; !ok {
+		*keys++
+		if o.maxKeys > 0 && *keys > o.maxKeys {
+			return &LimitError{Limit: LimitKeys, Max: int64(o.maxKeys)}
+		}
+	}
+	n.add(name, val)
+	if o.maxArrayLength > 0 && len(n.children[name]) > o.maxArrayLength {
+		return &LimitError{Limit: LimitArrayLength, Max: int64(o.maxArrayLength)}
+	}
+	return nil
+}
+
+func (n *xmlNode) value() (Value, error) {
+	if len(n.names) == 0 {
+		return StringVal(n.text.String()), nil
+	}
+	attrs := make(map[string]Value, len(n.names)+1)
+	for _, name := range n.names {
+		vals := n.children[name]
+		if len(vals) == 1 {
+			attrs[name] = vals[0]
+			continue
+		}
+		list := unifiedListVal(vals)
+		if err := list.GetError(); err != nil {
+			return NilVal, err
+		}
+		attrs[name] = list
+	}
+	if text := strings.TrimSpace(n.text.String()); text != "" {
+		attrs[xmlTextKey] = StringVal(text)
+	}
+	return ObjectVal(attrs), nil
+}
+
+func init() {
+	RegisterDecoder("application/xml", HttpRequestXMLVal)
+	RegisterDecoder("text/xml", HttpRequestXMLVal)
+}